@@ -1,17 +1,57 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
 	ui "github.com/Leda-Editor/Leda-Text-Editor/pkg/ui"
 )
 
 func main() {
+	// Parse the files, folders and options given on the command line.
+	args, err := handling.ParseArgs(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	// Read standard input before the window takes over.
+	var stdin []byte
+	if args.Stdin {
+		if stdin, err = io.ReadAll(os.Stdin); err != nil {
+			fmt.Fprintln(os.Stderr, "leda: reading standard input:", err)
+			os.Exit(1)
+		}
+	}
+
+	// Hand everything to the Leda that is already running, unless asked not to.
+	var listener net.Listener
+	socket, err := handling.SocketPath()
+	if err == nil {
+		listener, err = handling.Listen(socket)
+	}
+	if errors.Is(err, handling.ErrRunning) && !args.NewWindow {
+		if err := forward(socket, args, string(stdin)); err != nil {
+			fmt.Fprintln(os.Stderr, "leda:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize Fyne Application.
 	app := app.NewWithID("leda-text-editor")
 
 	// Create a new window for the application
-	window := app.NewWindow("Leda Text Editor")
+	window := app.NewWindow(ui.AppTitle)
 
 	// Initialize UI.
 	ledaUI := ui.NewUI(app, window)
@@ -19,8 +59,38 @@ func main() {
 	// Set up window layout.
 	window.SetContent(ledaUI.Layout())
 
+	// Accept files from Leda processes started later.
+	switch {
+	case err == nil:
+		ledaUI.Serve(listener)
+	case !errors.Is(err, handling.ErrRunning) && !errors.Is(err, errors.ErrUnsupported):
+		fyne.LogError("Failed to listen for other Leda processes", err)
+	}
+
+	// Open the command-line files, folders and standard input.
+	ledaUI.OpenArgs(args, string(stdin))
+
+	// Offer to restore buffers from a session that did not shut down cleanly.
+	ledaUI.RecoverSession()
+
 	// Set window size.
 	window.Resize(fyne.NewSize(900, 700))
 	// Display the window and start the event loop.
 	window.ShowAndRun()
 }
+
+// Send the command line to the running Leda, waiting for the files to be closed if asked to.
+func forward(socket string, args *handling.Args, stdin string) error {
+	client, err := handling.Dial(socket)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	for _, request := range handling.Requests(args, stdin) {
+		if err := client.Send(request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
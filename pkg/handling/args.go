@@ -0,0 +1,115 @@
+package handling
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Location is a path from the command line, with an optional 1-based line and column.
+type Location struct {
+	Path   string
+	Line   int
+	Column int
+}
+
+// Args holds the parsed command line.
+type Args struct {
+	// Paths are the files and folders to open.
+	Paths []Location
+	// Stdin asks for standard input to be read into an untitled buffer.
+	Stdin bool
+	// NewWindow opens a new window even when Leda is already running.
+	NewWindow bool
+	// ReadOnly opens the given files without allowing edits.
+	ReadOnly bool
+	// Wait keeps Leda running until the given files are closed.
+	Wait bool
+}
+
+// ParseArgs parses the command-line arguments, not including the program name.
+// Options may appear before or after the paths; "-" stands for standard input.
+func ParseArgs(args []string) (*Args, error) {
+	parsed := &Args{}
+	flags := flag.NewFlagSet("leda", flag.ContinueOnError)
+	flags.BoolVar(&parsed.NewWindow, "new-window", false, "open a new window instead of using a running Leda")
+	flags.BoolVar(&parsed.ReadOnly, "readonly", false, "open the files read-only")
+	flags.BoolVar(&parsed.Wait, "wait", false, "wait for the files to be closed before returning")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: leda [options] [file[:line[:column]] | folder | -]...")
+		flags.PrintDefaults()
+	}
+
+	for len(args) > 0 {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		// Parsing stops at "--", after which everything is a path.
+		terminated := len(args) > len(rest) && args[len(args)-len(rest)-1] == "--"
+
+		for len(rest) > 0 {
+			arg := rest[0]
+			rest = rest[1:]
+			if arg == "-" {
+				parsed.Stdin = true
+			} else {
+				location, err := ParseLocation(arg)
+				if err != nil {
+					return nil, err
+				}
+				parsed.Paths = append(parsed.Paths, location)
+			}
+			if !terminated {
+				break
+			}
+		}
+		args = rest
+	}
+	return parsed, nil
+}
+
+// ParseLocation splits a "path:line:column" argument and makes the path absolute.
+// A path that exists as written is never split, so names containing colons still open.
+func ParseLocation(arg string) (Location, error) {
+	location := Location{Path: arg}
+	if !exists(arg) {
+		if path, last, ok := cutNumber(arg); ok {
+			location.Path, location.Line = path, last
+			// With two numbers, the first is the line and the last the column,
+			// unless the path up to the first is a file of its own.
+			if path, line, ok := cutNumber(path); ok && !exists(location.Path) {
+				location.Path, location.Line, location.Column = path, line, last
+			}
+		}
+	}
+
+	path, err := filepath.Abs(location.Path)
+	if err != nil {
+		return location, err
+	}
+	location.Path = path
+	return location, nil
+}
+
+// Report whether anything exists at path.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Split a trailing ":number" off s.
+func cutNumber(s string) (string, int, bool) {
+	i := strings.LastIndexByte(s, ':')
+	if i <= 0 {
+		return s, 0, false
+	}
+	n, err := strconv.Atoi(s[i+1:])
+	if err != nil || n < 1 {
+		return s, 0, false
+	}
+	return s[:i], n, true
+}
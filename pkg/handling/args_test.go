@@ -0,0 +1,123 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseLocation(t *testing.T) {
+	dir := t.TempDir()
+	// A file whose name ends like a line number still opens as itself.
+	colons := filepath.Join(dir, "notes:12")
+	if err := os.WriteFile(colons, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "main.go")
+
+	tests := []struct {
+		name string
+		arg  string
+		want Location
+	}{
+		{"plain", missing, Location{Path: missing}},
+		{"line", missing + ":12", Location{Path: missing, Line: 12}},
+		{"line and column", missing + ":12:5", Location{Path: missing, Line: 12, Column: 5}},
+		{"existing name with a colon", colons, Location{Path: colons}},
+		{"existing name with a colon and a line", colons + ":3", Location{Path: colons, Line: 3}},
+		{"not a number", missing + ":abc", Location{Path: missing + ":abc"}},
+		{"zero is not a line", missing + ":0", Location{Path: missing + ":0"}},
+		{"negative is not a line", missing + ":-1", Location{Path: missing + ":-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLocation(tt.arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLocation(%q) = %+v, want %+v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLocationMakesPathsAbsolute(t *testing.T) {
+	got, err := ParseLocation("some/file.txt:4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := filepath.Abs("some/file.txt")
+	if got.Path != want || got.Line != 4 {
+		t.Errorf("ParseLocation = %+v, want %s at line 4", got, want)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	abs := func(path string) string {
+		path, _ = filepath.Abs(path)
+		return path
+	}
+	tests := []struct {
+		name  string
+		args  []string
+		want  Args
+		paths []Location
+	}{
+		{"nothing", nil, Args{}, nil},
+		{"stdin", []string{"-"}, Args{Stdin: true}, nil},
+		{"options before paths", []string{"--readonly", "--wait", "a.txt"}, Args{ReadOnly: true, Wait: true},
+			[]Location{{Path: abs("a.txt")}}},
+		{"options after paths", []string{"a.txt:3", "--new-window", "b.txt"}, Args{NewWindow: true},
+			[]Location{{Path: abs("a.txt"), Line: 3}, {Path: abs("b.txt")}}},
+		{"wait for stdin", []string{"--wait", "-"}, Args{Stdin: true, Wait: true}, nil},
+		{"stdin among paths", []string{"a.txt", "-", "b.txt"}, Args{Stdin: true},
+			[]Location{{Path: abs("a.txt")}, {Path: abs("b.txt")}}},
+		{"paths after --", []string{"--", "--readonly", "-x"}, Args{},
+			[]Location{{Path: abs("--readonly")}, {Path: abs("-x")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseArgs(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got.Paths, tt.paths) {
+				t.Errorf("paths = %+v, want %+v", got.Paths, tt.paths)
+			}
+			if got.Stdin != tt.want.Stdin || got.NewWindow != tt.want.NewWindow ||
+				got.ReadOnly != tt.want.ReadOnly || got.Wait != tt.want.Wait {
+				t.Errorf("options = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgsUnknownOption(t *testing.T) {
+	if _, err := ParseArgs([]string{"--bogus"}); err == nil {
+		t.Error("ParseArgs accepted an unknown option")
+	}
+}
+
+func TestRequestsWaitForStdin(t *testing.T) {
+	args, err := ParseArgs([]string{"--wait", "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := Requests(args, "piped")
+	want := []Request{
+		{Command: CommandNew, Content: "piped", Wait: true},
+		{Command: CommandFocus},
+		{Command: CommandWait},
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("Requests = %+v, want %+v", requests, want)
+	}
+
+	// Without --wait, nothing waits.
+	requests = Requests(&Args{Stdin: true}, "piped")
+	if last := requests[len(requests)-1]; last.Command == CommandWait || requests[0].Wait {
+		t.Errorf("Requests without --wait = %+v", requests)
+	}
+}
@@ -0,0 +1,601 @@
+package handling
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+)
+
+// pieceSize caps how many bytes one piece covers, so that looking inside a
+// piece never scans more than this.
+const pieceSize = 4096
+
+// maxPages limits how many pieces of a paged file are kept in memory.
+const maxPages = 1024
+
+// Buffer holds the text being edited as a piece table: the text it was loaded
+// with and an append-only buffer of inserted text, stitched together by a
+// balanced tree of pieces. Edits, line lookups and coordinate conversions take
+// O(log n) time. Positions are byte offsets; rows and columns count lines and
+// runes from zero.
+//
+// A buffer made by NewFileBuffer reads its original text from the file as
+// pieces are needed instead of holding it all.
+type Buffer struct {
+	original string
+	added    []byte
+	root     *piece
+
+	// source pages in the original text, when set.
+	source    io.ReaderAt
+	pages     map[[2]int]string
+	pagesLock sync.Mutex
+
+	// text caches the whole text until the next edit.
+	text   string
+	cached bool
+}
+
+// piece is a run of the original or added text, and a node of the tree that
+// orders the pieces. Each node also counts the bytes, line breaks and runes
+// of its whole subtree.
+type piece struct {
+	added         bool
+	start, length int
+	breaks, runes int
+
+	left, right *piece
+	priority    uint32
+
+	size, totalBreaks, totalRunes int
+}
+
+// NewBuffer creates a buffer holding text.
+func NewBuffer(text string) *Buffer {
+	b := &Buffer{}
+	b.SetText(text)
+	return b
+}
+
+// NewFileBuffer creates a buffer paging in size bytes of text from source.
+// It reads the text through once to index its lines.
+func NewFileBuffer(source io.ReaderAt, size int64) (*Buffer, error) {
+	b := &Buffer{source: source, pages: map[[2]int]string{}}
+	data := make([]byte, pieceSize+1)
+	for start := 0; int64(start) < size; {
+		n, err := source.ReadAt(data[:min(int64(len(data)), size-int64(start))], int64(start))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+		text := string(data[:n])
+		end := chunkEnd(text, 0)
+		b.root = merge(b.root, b.newPieceOf(false, start, text[:end]))
+		start += end
+	}
+	return b, nil
+}
+
+// SetText replaces the whole text, closing any file the buffer paged from.
+func (b *Buffer) SetText(text string) {
+	b.Close()
+	b.original = text
+	b.added = nil
+	b.root = nil
+	b.text, b.cached = text, true
+	for start := 0; start < len(text); {
+		end := chunkEnd(text, start)
+		b.root = merge(b.root, b.newPiece(false, start, end-start))
+		start = end
+	}
+}
+
+// Paged reports whether the buffer reads its text from a file as needed.
+func (b *Buffer) Paged() bool {
+	return b.source != nil
+}
+
+// Detach reads the rest of a paged buffer's file into memory and closes it,
+// so that the file can be overwritten.
+func (b *Buffer) Detach() error {
+	if b.source == nil {
+		return nil
+	}
+	// The pieces only move to the text in memory once all of it has been read,
+	// so a failed read leaves them paging from the file as before.
+	var s strings.Builder
+	starts := map[*piece]int{}
+	err := b.walk(b.root, func(n *piece) error {
+		if n.added {
+			return nil
+		}
+		text, err := b.read(n.start, n.length)
+		if err != nil {
+			return err
+		}
+		starts[n] = s.Len()
+		s.WriteString(text)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for n, start := range starts {
+		n.start = start
+	}
+	b.original = s.String()
+	return b.Close()
+}
+
+// Snapshot returns a copy of the buffer that later edits leave alone, so that
+// it can be read on another goroutine. The text is shared, the tree is not.
+func (b *Buffer) Snapshot() *Buffer {
+	s := &Buffer{
+		original: b.original,
+		added:    b.added[:len(b.added):len(b.added)],
+		root:     b.root.clone(),
+		source:   b.source,
+		text:     b.text,
+		cached:   b.cached,
+	}
+	if b.source != nil {
+		s.pages = map[[2]int]string{}
+	}
+	return s
+}
+
+// Close closes the file a paged buffer reads from.
+func (b *Buffer) Close() error {
+	source := b.source
+	b.source, b.pages = nil, nil
+	if closer, ok := source.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// String returns the whole text. The text of a paged buffer is not kept.
+func (b *Buffer) String() string {
+	if !b.cached {
+		var s strings.Builder
+		s.Grow(b.Len())
+		b.each(b.root, 0, b.Len(), func(text string) { s.WriteString(text) })
+		if b.source != nil {
+			return s.String()
+		}
+		b.text, b.cached = s.String(), true
+	}
+	return b.text
+}
+
+// WriteTo writes the whole text to w a piece at a time.
+func (b *Buffer) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	err := b.walk(b.root, func(n *piece) error {
+		text, err := b.pieceContent(n)
+		if err != nil {
+			return err
+		}
+		count, err := io.WriteString(w, text)
+		written += int64(count)
+		return err
+	})
+	return written, err
+}
+
+// Len returns the length of the text in bytes.
+func (b *Buffer) Len() int {
+	return b.root.sizeOf()
+}
+
+// RuneCount returns the length of the text in runes.
+func (b *Buffer) RuneCount() int {
+	return b.root.runesOf()
+}
+
+// Lines returns the number of lines, which is one more than the number of line breaks.
+func (b *Buffer) Lines() int {
+	return b.root.breaksOf() + 1
+}
+
+// Line returns the text of a line without its line break.
+func (b *Buffer) Line(row int) string {
+	if row < 0 || row >= b.Lines() {
+		return ""
+	}
+	return b.Slice(b.LineStart(row), b.lineEnd(row))
+}
+
+// LineStart returns the offset where a line starts.
+func (b *Buffer) LineStart(row int) int {
+	row = max(0, min(row, b.Lines()-1))
+	if row == 0 {
+		return 0
+	}
+	// Find the line break ending the previous line.
+	n, base := b.root, 0
+	for {
+		if row <= n.left.breaksOf() {
+			n = n.left
+			continue
+		}
+		row -= n.left.breaksOf()
+		base += n.left.sizeOf()
+		if row <= n.breaks {
+			text := b.pieceText(n)
+			pos := 0
+			for ; row > 0; row-- {
+				pos += strings.IndexByte(text[pos:], '\n') + 1
+			}
+			return base + pos
+		}
+		row -= n.breaks
+		base += n.length
+		n = n.right
+	}
+}
+
+// Slice returns the text between two offsets.
+func (b *Buffer) Slice(start, end int) string {
+	if b.cached {
+		return b.text[start:end]
+	}
+	var s strings.Builder
+	s.Grow(end - start)
+	b.each(b.root, start, end, func(text string) { s.WriteString(text) })
+	return s.String()
+}
+
+// Insert adds text at an offset.
+func (b *Buffer) Insert(pos int, text string) {
+	b.Replace(pos, pos, text)
+}
+
+// Delete removes the text between two offsets.
+func (b *Buffer) Delete(start, end int) {
+	b.Replace(start, end, "")
+}
+
+// Replace swaps the text between start and end for text.
+func (b *Buffer) Replace(start, end int, text string) {
+	if start == end && text == "" {
+		return
+	}
+	b.text, b.cached = "", false
+
+	left, rest := b.split(b.root, start)
+	_, right := b.split(rest, end-start)
+
+	// Typing extends the piece it types after instead of adding one per key.
+	if text != "" && b.extend(left, text) {
+		text = ""
+	}
+	var middle *piece
+	for from := 0; from < len(text); {
+		to := chunkEnd(text, from)
+		at := len(b.added)
+		b.added = append(b.added, text[from:to]...)
+		middle = merge(middle, b.newPiece(true, at, to-from))
+		from = to
+	}
+	b.root = merge(merge(left, middle), right)
+}
+
+// RowCol converts an offset into a row and a column in runes.
+func (b *Buffer) RowCol(pos int) (int, int) {
+	pos = max(0, min(pos, b.Len()))
+	breaks, runes := b.count(pos)
+	return breaks, runes - b.RuneOffset(b.LineStart(breaks))
+}
+
+// Offset converts a row and column into an offset, clamped to the text.
+func (b *Buffer) Offset(row, col int) int {
+	if row < 0 {
+		return 0
+	}
+	if row >= b.Lines() {
+		return b.Len()
+	}
+	start := b.LineStart(row)
+	return min(b.ByteOffset(b.RuneOffset(start)+max(col, 0)), b.lineEnd(row))
+}
+
+// RuneOffset converts a byte offset into the number of runes before it.
+func (b *Buffer) RuneOffset(pos int) int {
+	_, runes := b.count(max(0, min(pos, b.Len())))
+	return runes
+}
+
+// ByteOffset converts a count of runes from the start into a byte offset.
+func (b *Buffer) ByteOffset(runes int) int {
+	if runes >= b.RuneCount() {
+		return b.Len()
+	}
+	n, base := b.root, 0
+	for runes > 0 {
+		if runes < n.left.runesOf() {
+			n = n.left
+			continue
+		}
+		runes -= n.left.runesOf()
+		base += n.left.sizeOf()
+		if runes < n.runes {
+			text := b.pieceText(n)
+			pos := 0
+			for ; runes > 0; runes-- {
+				_, size := utf8.DecodeRuneInString(text[pos:])
+				pos += size
+			}
+			return base + pos
+		}
+		runes -= n.runes
+		base += n.length
+		n = n.right
+	}
+	return base
+}
+
+// Offset where a line's text ends, before its line break.
+func (b *Buffer) lineEnd(row int) int {
+	if row+1 < b.Lines() {
+		return b.LineStart(row+1) - 1
+	}
+	return b.Len()
+}
+
+// Count the line breaks and runes before an offset.
+func (b *Buffer) count(pos int) (int, int) {
+	breaks, runes := 0, 0
+	for n := b.root; n != nil && pos > 0; {
+		if pos <= n.left.sizeOf() {
+			n = n.left
+			continue
+		}
+		pos -= n.left.sizeOf()
+		breaks += n.left.breaksOf()
+		runes += n.left.runesOf()
+		if pos < n.length {
+			text := b.pieceText(n)[:pos]
+			return breaks + strings.Count(text, "\n"), runes + utf8.RuneCountInString(text)
+		}
+		pos -= n.length
+		breaks += n.breaks
+		runes += n.runes
+		n = n.right
+	}
+	return breaks, runes
+}
+
+// Call fn with the text of each piece between start and end, in order.
+// Offsets are relative to the subtree n.
+func (b *Buffer) each(n *piece, start, end int, fn func(string)) {
+	if n == nil || start >= end {
+		return
+	}
+	leftSize := n.left.sizeOf()
+	if start < leftSize {
+		b.each(n.left, start, min(end, leftSize), fn)
+	}
+	from, to := max(start-leftSize, 0), min(end-leftSize, n.length)
+	if from < to {
+		fn(b.pieceText(n)[from:to])
+	}
+	rightStart := leftSize + n.length
+	if end > rightStart {
+		b.each(n.right, max(start-rightStart, 0), end-rightStart, fn)
+	}
+}
+
+// Call fn with each piece of a subtree in order, stopping at the first error.
+func (b *Buffer) walk(n *piece, fn func(*piece) error) error {
+	if n == nil {
+		return nil
+	}
+	if err := b.walk(n.left, fn); err != nil {
+		return err
+	}
+	if err := fn(n); err != nil {
+		return err
+	}
+	return b.walk(n.right, fn)
+}
+
+// The text a piece covers. A page that can no longer be read is logged and
+// shown blank, as the drawing code has no way to report it.
+func (b *Buffer) pieceText(n *piece) string {
+	text, err := b.pieceContent(n)
+	if err != nil {
+		fyne.LogError("Failed to read file", err)
+		return strings.Repeat(" ", n.length)
+	}
+	return text
+}
+
+// The text a piece covers, reading it from the file when paged.
+func (b *Buffer) pieceContent(n *piece) (string, error) {
+	if n.added {
+		return string(b.added[n.start : n.start+n.length]), nil
+	}
+	if b.source != nil {
+		return b.page(n.start, n.length)
+	}
+	return b.original[n.start : n.start+n.length], nil
+}
+
+// Read part of a paged file, keeping the most recent parts read.
+func (b *Buffer) page(start, length int) (string, error) {
+	b.pagesLock.Lock()
+	defer b.pagesLock.Unlock()
+
+	key := [2]int{start, length}
+	if text, ok := b.pages[key]; ok {
+		return text, nil
+	}
+	text, err := b.read(start, length)
+	if err != nil {
+		return "", err
+	}
+	if len(b.pages) >= maxPages {
+		clear(b.pages)
+	}
+	b.pages[key] = text
+	return text, nil
+}
+
+// Read part of the original text from a paged file.
+func (b *Buffer) read(start, length int) (string, error) {
+	data := make([]byte, length)
+	n, err := b.source.ReadAt(data, int64(start))
+	if n == length {
+		return string(data), nil
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return "", err
+}
+
+// Create a piece covering text already in the original or added buffer.
+func (b *Buffer) newPiece(added bool, start, length int) *piece {
+	return b.newPieceOf(added, start, b.pieceText(&piece{added: added, start: start, length: length}))
+}
+
+// Create a piece covering text at start in the original or added buffer.
+func (b *Buffer) newPieceOf(added bool, start int, text string) *piece {
+	n := &piece{added: added, start: start, length: len(text), priority: rand.Uint32()}
+	n.breaks = strings.Count(text, "\n")
+	n.runes = utf8.RuneCountInString(text)
+	n.update()
+	return n
+}
+
+// Split a subtree into the pieces before and after an offset, cutting the
+// piece it falls inside in two.
+func (b *Buffer) split(n *piece, pos int) (*piece, *piece) {
+	if n == nil {
+		return nil, nil
+	}
+	leftSize := n.left.sizeOf()
+	switch {
+	case pos <= leftSize:
+		left, right := b.split(n.left, pos)
+		n.left = right
+		n.update()
+		return left, n
+	case pos >= leftSize+n.length:
+		left, right := b.split(n.right, pos-leftSize-n.length)
+		n.right = left
+		n.update()
+		return n, right
+	}
+
+	cut := pos - leftSize
+	after := b.newPiece(n.added, n.start+cut, n.length-cut)
+	n.length = cut
+	text := b.pieceText(n)
+	n.breaks = strings.Count(text, "\n")
+	n.runes = utf8.RuneCountInString(text)
+	right := n.right
+	n.right = nil
+	n.update()
+	return n, merge(after, right)
+}
+
+// Grow the last piece of a subtree by text when it ends where the added
+// buffer does, reporting whether it could.
+func (b *Buffer) extend(n *piece, text string) bool {
+	if n == nil {
+		return false
+	}
+	if n.right != nil {
+		if !b.extend(n.right, text) {
+			return false
+		}
+		n.update()
+		return true
+	}
+	if !n.added || n.start+n.length != len(b.added) || n.length+len(text) > pieceSize {
+		return false
+	}
+	b.added = append(b.added, text...)
+	n.length += len(text)
+	n.breaks += strings.Count(text, "\n")
+	n.runes += utf8.RuneCountInString(text)
+	n.update()
+	return true
+}
+
+// Join two subtrees, with every piece of left before those of right.
+func merge(left, right *piece) *piece {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	if left.priority > right.priority {
+		left.right = merge(left.right, right)
+		left.update()
+		return left
+	}
+	right.left = merge(left, right.left)
+	right.update()
+	return right
+}
+
+// Recount a node's subtree from its children.
+func (n *piece) update() {
+	n.size = n.left.sizeOf() + n.length + n.right.sizeOf()
+	n.totalBreaks = n.left.breaksOf() + n.breaks + n.right.breaksOf()
+	n.totalRunes = n.left.runesOf() + n.runes + n.right.runesOf()
+}
+
+// Copy a subtree.
+func (n *piece) clone() *piece {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.left, c.right = n.left.clone(), n.right.clone()
+	return &c
+}
+
+func (n *piece) sizeOf() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *piece) breaksOf() int {
+	if n == nil {
+		return 0
+	}
+	return n.totalBreaks
+}
+
+func (n *piece) runesOf() int {
+	if n == nil {
+		return 0
+	}
+	return n.totalRunes
+}
+
+// Where the piece of text starting at start should end, without splitting a rune.
+func chunkEnd(text string, start int) int {
+	end := start + pieceSize
+	if end >= len(text) {
+		return len(text)
+	}
+	for end > start+1 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return end
+}
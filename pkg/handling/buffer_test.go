@@ -0,0 +1,231 @@
+package handling
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// Pieces of text to edit with, including line breaks and multi-byte runes.
+var bufferPieces = []string{"a", "bc", "\n", "é", "日本", "\n\n", "x\ny", "🙂", strings.Repeat("long line ", 50)}
+
+// Edit b and a plain string model of it in step, checking them against each other after every edit.
+func checkRandomEdits(t *testing.T, b *Buffer, model string, seed int64) {
+	t.Helper()
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < 2000; i++ {
+		start := runeBoundary(model, r.Intn(len(model)+1))
+		end := runeBoundary(model, start+r.Intn(min(20, len(model)-start)+1))
+		switch r.Intn(3) {
+		case 0:
+			text := bufferPieces[r.Intn(len(bufferPieces))]
+			b.Insert(start, text)
+			model = model[:start] + text + model[start:]
+		case 1:
+			b.Delete(start, end)
+			model = model[:start] + model[end:]
+		default:
+			text := bufferPieces[r.Intn(len(bufferPieces))]
+			b.Replace(start, end, text)
+			model = model[:start] + text + model[end:]
+		}
+		if !checkBuffer(t, b, model, r) {
+			t.Fatalf("buffer differs from the model after edit %d", i)
+		}
+	}
+}
+
+// Check every query on b against model at some random places, reporting whether all agreed.
+func checkBuffer(t *testing.T, b *Buffer, model string, r *rand.Rand) bool {
+	t.Helper()
+	ok := true
+	fail := func(format string, args ...any) {
+		t.Helper()
+		t.Errorf(format, args...)
+		ok = false
+	}
+
+	if b.Len() != len(model) {
+		fail("Len = %d, want %d", b.Len(), len(model))
+	}
+	if want := utf8.RuneCountInString(model); b.RuneCount() != want {
+		fail("RuneCount = %d, want %d", b.RuneCount(), want)
+	}
+	lines := strings.Split(model, "\n")
+	starts := make([]int, len(lines))
+	for i := 1; i < len(lines); i++ {
+		starts[i] = starts[i-1] + len(lines[i-1]) + 1
+	}
+	if b.Lines() != len(lines) {
+		fail("Lines = %d, want %d", b.Lines(), len(lines))
+	}
+
+	// Rows near both ends and some in between.
+	rows := []int{0, len(lines) - 1}
+	for range 5 {
+		rows = append(rows, r.Intn(len(lines)))
+	}
+	for _, row := range rows {
+		if got := b.LineStart(row); got != starts[row] {
+			fail("LineStart(%d) = %d, want %d", row, got, starts[row])
+		}
+		if got := b.Line(row); got != lines[row] {
+			fail("Line(%d) = %q, want %q", row, got, lines[row])
+		}
+	}
+
+	for range 5 {
+		pos := runeBoundary(model, r.Intn(len(model)+1))
+		lineStart := strings.LastIndexByte(model[:pos], '\n') + 1
+		wantRow, wantCol := strings.Count(model[:pos], "\n"), utf8.RuneCountInString(model[lineStart:pos])
+		if row, col := b.RowCol(pos); row != wantRow || col != wantCol {
+			fail("RowCol(%d) = %d, %d, want %d, %d", pos, row, col, wantRow, wantCol)
+		}
+		if got := b.Offset(wantRow, wantCol); got != pos {
+			fail("Offset(%d, %d) = %d, want %d", wantRow, wantCol, got, pos)
+		}
+		runes := utf8.RuneCountInString(model[:pos])
+		if got := b.RuneOffset(pos); got != runes {
+			fail("RuneOffset(%d) = %d, want %d", pos, got, runes)
+		}
+		if got := b.ByteOffset(runes); got != pos {
+			fail("ByteOffset(%d) = %d, want %d", runes, got, pos)
+		}
+
+		end := runeBoundary(model, pos+r.Intn(len(model)-pos+1))
+		if got := b.Slice(pos, end); got != model[pos:end] {
+			fail("Slice(%d, %d) = %q, want %q", pos, end, got, model[pos:end])
+		}
+	}
+
+	// String caches the text, which must then agree with the tree as well.
+	if r.Intn(10) == 0 && b.String() != model {
+		fail("String differs from the model")
+	}
+	return ok
+}
+
+// Move pos back to the start of the rune it falls in.
+func runeBoundary(s string, pos int) int {
+	for pos > 0 && pos < len(s) && !utf8.RuneStart(s[pos]) {
+		pos--
+	}
+	return pos
+}
+
+func TestBufferRandomEdits(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		checkRandomEdits(t, NewBuffer(""), "", seed)
+	}
+}
+
+func TestBufferRandomEditsOfText(t *testing.T) {
+	// Enough text to span many pieces before any edit.
+	text := strings.Repeat("line one\nsecond lïne 日本\n\n", 2000)
+	checkRandomEdits(t, NewBuffer(text), text, 1)
+}
+
+func TestFileBufferRandomEdits(t *testing.T) {
+	text := strings.Repeat("paged text\nwith é and 🙂\n", 2000)
+	b, err := NewFileBuffer(strings.NewReader(text), int64(len(text)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRandomEdits(t, b, text, 1)
+}
+
+func TestBufferSnapshot(t *testing.T) {
+	b := NewBuffer("hello\nworld")
+	snapshot := b.Snapshot()
+	b.Insert(5, ", there")
+	b.Delete(0, 1)
+	if got := snapshot.String(); got != "hello\nworld" {
+		t.Errorf("snapshot = %q after editing the buffer", got)
+	}
+	snapshot.Insert(0, ">")
+	if got := b.String(); got != "ello, there\nworld" {
+		t.Errorf("buffer = %q after editing the snapshot", got)
+	}
+}
+
+// benchmarkSize is the size of the text the benchmarks edit, in bytes.
+const benchmarkSize = 32 << 20
+
+// A buffer of benchmarkSize bytes of source-like lines.
+func benchmarkBuffer() *Buffer {
+	line := "\tfor i := 0; i < len(items); i++ { total += items[i].value } // sum\n"
+	return NewBuffer(strings.Repeat(line, benchmarkSize/len(line)))
+}
+
+func BenchmarkInsert(b *testing.B) {
+	buffer := benchmarkBuffer()
+	r := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for range b.N {
+		buffer.Insert(r.Intn(buffer.Len()+1), "x")
+	}
+}
+
+func BenchmarkDelete(b *testing.B) {
+	buffer := benchmarkBuffer()
+	r := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for range b.N {
+		start := r.Intn(buffer.Len())
+		buffer.Delete(start, min(start+1, buffer.Len()))
+	}
+}
+
+// Search the whole text after each edit, as the search sidebar does while typing.
+func BenchmarkSearch(b *testing.B) {
+	buffer := benchmarkBuffer()
+	matcher, err := NewMatcher("total", SearchOptions{CaseSensitive: true})
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for range b.N {
+		buffer.Insert(r.Intn(buffer.Len()+1), "x")
+		if len(matcher.FindAll(buffer.String())) == 0 {
+			b.Fatal("no matches")
+		}
+	}
+}
+
+// failingReader fails every read once its allowed reads are used up.
+type failingReader struct {
+	*strings.Reader
+	allowed int
+}
+
+func (r *failingReader) ReadAt(p []byte, off int64) (int, error) {
+	if r.allowed == 0 {
+		return 0, errors.New("read failed")
+	}
+	r.allowed--
+	return r.Reader.ReadAt(p, off)
+}
+
+func TestBufferDetachFailureKeepsText(t *testing.T) {
+	text := strings.Repeat("0123456789\n", 2000)
+	reader := &failingReader{Reader: strings.NewReader(text), allowed: -1}
+	b, err := NewFileBuffer(reader, int64(len(text)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With text deleted from the front, the pieces would move if Detach went part way.
+	b.Delete(0, 5000)
+	text = text[5000:]
+	// Fail part way through reading the file in.
+	reader.allowed = 3
+	if err := b.Detach(); err == nil {
+		t.Fatal("Detach succeeded despite failing reads")
+	}
+	reader.allowed = -1
+	if got := b.String(); got != text {
+		t.Error("the text changed after a failed Detach")
+	}
+}
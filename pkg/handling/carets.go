@@ -0,0 +1,287 @@
+package handling
+
+import (
+	"slices"
+	"strings"
+)
+
+// caret is one of several cursors, selecting from anchor to head. Both are
+// byte offsets; the selection is empty when they are equal.
+type caret struct {
+	anchor, head int
+}
+
+// The ordered offsets of the caret's selection.
+func (c caret) ordered() (int, int) {
+	return min(c.anchor, c.head), max(c.anchor, c.head)
+}
+
+// AddCaret adds a cursor at pos, keeping the others, or removes the extra
+// cursor already there.
+func (e *Editor) AddCaret(pos int) {
+	for i, c := range e.carets {
+		if c.head == pos {
+			e.carets = slices.Delete(e.carets, i, i+1)
+			e.cursorMoved()
+			return
+		}
+	}
+	e.AddSelection(pos, pos)
+}
+
+// AddSelection adds a cursor selecting from start to end, keeping the others.
+// It becomes the main cursor, which the view follows.
+func (e *Editor) AddSelection(start, end int) {
+	e.carets = append(e.carets, e.primary())
+	e.goalColumn = -1
+	e.selectMain(start, end)
+}
+
+// Carets returns how many cursors there are.
+func (e *Editor) Carets() int {
+	return len(e.carets) + 1
+}
+
+// Selection returns the ordered offsets of the main cursor's selection, which
+// are equal when nothing is selected.
+func (e *Editor) Selection() (int, int) {
+	return e.primary().ordered()
+}
+
+// IsSelected reports whether a cursor selects exactly from start to end.
+func (e *Editor) IsSelected(start, end int) bool {
+	cs, _ := e.allCarets()
+	for _, c := range cs {
+		if from, to := c.ordered(); from == start && to == end {
+			return true
+		}
+	}
+	return false
+}
+
+// Select from start to end with the main cursor, keeping the others.
+func (e *Editor) selectMain(start, end int) {
+	e.anchorRow, e.anchorColumn = e.RowCol(start)
+	e.CursorRow, e.CursorColumn = e.RowCol(end)
+	e.selecting = start != end
+	e.cursorMoved()
+}
+
+// The main cursor as a caret.
+func (e *Editor) primary() caret {
+	head := e.CursorOffset()
+	if !e.selecting {
+		return caret{head, head}
+	}
+	return caret{e.Offset(e.anchorRow, e.anchorColumn), head}
+}
+
+// Every cursor in order through the text, and which of them is the main one.
+func (e *Editor) allCarets() ([]caret, int) {
+	main := e.primary()
+	cs := append(slices.Clone(e.carets), main)
+	slices.SortStableFunc(cs, func(a, b caret) int {
+		from, _ := a.ordered()
+		other, _ := b.ordered()
+		return from - other
+	})
+	return cs, slices.Index(cs, main)
+}
+
+// Make cs the cursors, with cs[primary] the main one.
+func (e *Editor) setCarets(cs []caret, primary int) {
+	main := cs[primary]
+	e.anchorRow, e.anchorColumn = e.RowCol(main.anchor)
+	e.CursorRow, e.CursorColumn = e.RowCol(main.head)
+	e.selecting = main.anchor != main.head
+	e.carets = append(slices.Clone(cs[:primary]), cs[primary+1:]...)
+}
+
+// Join cursors that overlap or sit at the same place.
+func (e *Editor) mergeCarets() {
+	if len(e.carets) == 0 {
+		return
+	}
+	cs, primary := e.allCarets()
+	merged, main := []caret{cs[0]}, 0
+	for i, c := range cs[1:] {
+		last := &merged[len(merged)-1]
+		from, to := last.ordered()
+		start, end := c.ordered()
+		if start == from || start < to || start == to && (from == to || start == end) {
+			if end > to {
+				*last = caret{from, end}
+			}
+		} else {
+			merged = append(merged, c)
+		}
+		if i+1 == primary {
+			main = len(merged) - 1
+		}
+	}
+	e.setCarets(merged, main)
+}
+
+// Drop the extra cursors, keeping the main one.
+func (e *Editor) clearCarets() {
+	e.carets = nil
+}
+
+// Select a block from one row and visual column to another, with a cursor on each line.
+func (e *Editor) selectBlock(fromRow, fromVisual, toRow, toVisual int) {
+	var cs []caret
+	step := 1
+	if toRow < fromRow {
+		step = -1
+	}
+	for row := fromRow; ; row += step {
+		line := e.Buffer.Line(row)
+		cs = append(cs, caret{
+			anchor: e.Offset(row, runeColumn(line, fromVisual)),
+			head:   e.Offset(row, runeColumn(line, toVisual)),
+		})
+		if row == toRow {
+			break
+		}
+	}
+	e.goalColumn = -1
+	e.setCarets(cs, len(cs)-1)
+	e.cursorMoved()
+}
+
+// Move the extra cursors to where next finds, extending their selections while shift is held.
+// The main cursor moves separately.
+func (e *Editor) moveCarets(next func(c caret) int) {
+	for i, c := range e.carets {
+		c.head = next(c)
+		if !e.shift {
+			c.anchor = c.head
+		}
+		e.carets[i] = c
+	}
+}
+
+// The offset a cursor moves to going up or down by rows, keeping its visual column.
+func (e *Editor) caretLines(c caret, rows int) int {
+	row, col := e.RowCol(c.head)
+	visual := visualColumn(e.Buffer.Line(row), col)
+	row = max(0, min(row+rows, e.Buffer.Lines()-1))
+	return e.Offset(row, runeColumn(e.Buffer.Line(row), visual))
+}
+
+// The text selected by every cursor, one selection per line.
+func (e *Editor) selectedTexts() string {
+	cs, _ := e.allCarets()
+	var texts []string
+	for _, c := range cs {
+		if start, end := c.ordered(); start != end {
+			texts = append(texts, e.Buffer.Slice(start, end))
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Replace the selection of every cursor with the text for it. Given several
+// cursors and as many lines, each cursor gets one line.
+func (e *Editor) paste(text string) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(e.carets) > 0 && len(lines) == len(e.carets)+1 {
+		e.insertEach(func(i int, _ caret) string { return lines[i] })
+		return
+	}
+	e.insert(text)
+}
+
+// Replace the selection of every cursor with text.
+func (e *Editor) insert(text string) {
+	e.insertEach(func(int, caret) string { return text })
+}
+
+// Replace the selection of every cursor with the text for it, the i-th cursor
+// counting through the text.
+func (e *Editor) insertEach(text func(i int, c caret) string) {
+	e.editCarets(func(i int, c caret) (int, int, string) {
+		start, end := c.ordered()
+		return start, end, text(i, c)
+	})
+}
+
+// Delete the selection of every cursor, or from each cursor to where next finds.
+func (e *Editor) erase(next func(int) int) {
+	e.editCarets(func(_ int, c caret) (int, int, string) {
+		if start, end := c.ordered(); start != end {
+			return start, end, ""
+		}
+		other := next(c.head)
+		return min(c.head, other), max(c.head, other), ""
+	})
+}
+
+// Make an edit at every cursor as one undoable step. edit returns the range to
+// replace and its replacement for the i-th cursor counting through the text.
+func (e *Editor) editCarets(edit func(i int, c caret) (int, int, string)) {
+	cs, primary := e.allCarets()
+
+	// Edit from the end backwards, so the offsets of the edits still to make stay put.
+	edits := make([]Edit, 0, len(cs))
+	limit, changed := e.Buffer.Len(), false
+	for i := len(cs) - 1; i >= 0; i-- {
+		start, end, text := edit(i, cs[i])
+		end = min(end, limit)
+		start = min(start, end)
+		limit = start
+		edits = append(edits, Edit{Pos: start, Deleted: e.Buffer.Slice(start, end), Inserted: text})
+		changed = changed || start != end || text != ""
+	}
+	if !changed {
+		return
+	}
+	e.History.Add(edits...)
+	e.applyEdits(edits, len(cs)-1-primary)
+}
+
+// Make edits to the buffer in order, leaving a cursor after each one's
+// inserted text, with the one after edits[primary] the main cursor.
+func (e *Editor) applyEdits(edits []Edit, primary int) {
+	others := e.otherCarets()
+	ends := make([]int, 0, len(edits))
+	for _, edit := range edits {
+		row, _ := e.RowCol(edit.Pos)
+		e.Buffer.Replace(edit.Pos, edit.Pos+len(edit.Deleted), edit.Inserted)
+		e.highlightEdit(row, strings.Count(edit.Deleted, "\n")+1, strings.Count(edit.Inserted, "\n")+1)
+		shift := len(edit.Inserted) - len(edit.Deleted)
+		for i := range ends {
+			if ends[i] > edit.Pos {
+				ends[i] += shift
+			}
+		}
+		ends = append(ends, edit.Pos+len(edit.Inserted))
+	}
+
+	cs := make([]caret, len(ends))
+	for i, end := range ends {
+		cs[i] = caret{end, end}
+		first, _ := e.RowCol(end - len(edits[i].Inserted))
+		last, _ := e.RowCol(end)
+		e.content.widen(first, last)
+	}
+	e.goalColumn = -1
+	e.setCarets(cs, primary)
+	e.content.linesChanged()
+	e.editsMade(edits, others)
+	e.changed()
+	e.edited()
+}
+
+// Tell the highlighter that the removed lines from row on are now added lines
+// of the buffer, so only those are lexed again.
+func (e *Editor) highlightEdit(row, removed, added int) {
+	if e.Highlighter == nil {
+		return
+	}
+	lines := make([]string, added)
+	for i := range lines {
+		lines[i] = e.Buffer.Line(row + i)
+	}
+	e.Highlighter.Replace(row, removed, lines)
+}
@@ -0,0 +1,169 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// Apply edits to text in order, as the buffer does.
+func applyToString(text string, edits []Edit) string {
+	for _, edit := range edits {
+		text = text[:edit.Pos] + edit.Inserted + text[edit.Pos+len(edit.Deleted):]
+	}
+	return text
+}
+
+// The edits of the last undo step.
+func lastStep(e *Editor) []Edit {
+	return e.History.undo[len(e.History.undo)-1]
+}
+
+// An editor holding text, with cursors selecting each of carets and the last the main one.
+func editorWithCarets(text string, carets ...caret) *Editor {
+	e := NewEditor()
+	e.Load(text)
+	e.selectMain(carets[0].anchor, carets[0].head)
+	for _, c := range carets[1:] {
+		e.AddSelection(c.anchor, c.head)
+	}
+	return e
+}
+
+func TestEditCarets(t *testing.T) {
+	test.NewApp()
+	tests := []struct {
+		name    string
+		text    string
+		carets  []caret
+		edit    func(i int, c caret) (int, int, string)
+		want    string
+		wantCs  []caret
+		primary caret
+	}{
+		{
+			"insert at each cursor",
+			"ab\ncd\nef", []caret{{0, 0}, {3, 3}, {6, 6}},
+			func(_ int, c caret) (int, int, string) { return c.head, c.head, "> " },
+			"> ab\n> cd\n> ef", []caret{{2, 2}, {7, 7}, {12, 12}}, caret{12, 12},
+		},
+		{
+			"replace each selection with its own text",
+			"one two three", []caret{{8, 13}, {0, 3}},
+			func(i int, c caret) (int, int, string) {
+				start, end := c.ordered()
+				return start, end, []string{"1", "3"}[i]
+			},
+			"1 two 3", []caret{{1, 1}, {7, 7}}, caret{1, 1},
+		},
+		{
+			"main cursor in the middle",
+			"abc", []caret{{0, 0}, {3, 3}, {1, 1}},
+			func(_ int, c caret) (int, int, string) { return c.head, c.head, "-" },
+			"-a-bc-", []caret{{1, 1}, {3, 3}, {6, 6}}, caret{3, 3},
+		},
+		{
+			// Each deletes the three bytes before it, so the later one takes
+			// what they share and the earlier one the rest.
+			"overlapping deletes",
+			"abcdefgh", []caret{{4, 4}, {6, 6}},
+			func(_ int, c caret) (int, int, string) { return max(0, c.head-3), c.head, "" },
+			"agh", []caret{{1, 1}}, caret{1, 1},
+		},
+		{
+			"ranges past the end",
+			"abc", []caret{{1, 1}, {3, 3}},
+			func(_ int, c caret) (int, int, string) { return c.head, c.head + 5, "x" },
+			"axx", []caret{{2, 2}, {3, 3}}, caret{3, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := editorWithCarets(tt.text, tt.carets...)
+			e.editCarets(tt.edit)
+
+			if got := e.Text(); got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+			// The step is made from the end of the text backwards, so each edit
+			// applies at its own offsets and undoing it rebuilds the text.
+			edits := lastStep(e)
+			for i := 1; i < len(edits); i++ {
+				if edits[i].Pos+len(edits[i].Deleted) > edits[i-1].Pos {
+					t.Errorf("edit %d %v is not before edit %d %v", i, edits[i], i-1, edits[i-1])
+				}
+			}
+			if got := applyToString(tt.text, edits); got != tt.want {
+				t.Errorf("the step's edits make %q, want %q", got, tt.want)
+			}
+			if got := caretsOf(e); !reflect.DeepEqual(got, tt.wantCs) {
+				t.Errorf("cursors = %v, want %v", got, tt.wantCs)
+			}
+			if got := e.primary(); got != tt.primary {
+				t.Errorf("main cursor = %v, want %v", got, tt.primary)
+			}
+
+			e.Undo()
+			if got := e.Text(); got != tt.text {
+				t.Errorf("text after undo = %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestEditCaretsWithoutChangeMakesNoStep(t *testing.T) {
+	test.NewApp()
+	e := editorWithCarets("abc", caret{1, 1}, caret{2, 2})
+	e.editCarets(func(_ int, c caret) (int, int, string) { return c.head, c.head, "" })
+	if e.History.CanUndo() {
+		t.Errorf("an empty edit made an undo step %v", lastStep(e))
+	}
+}
+
+func TestSelectBlock(t *testing.T) {
+	test.NewApp()
+	tests := []struct {
+		name                string
+		text                string
+		fromRow, fromVisual int
+		toRow, toVisual     int
+		want                []caret
+		primary             caret
+	}{
+		{
+			"down and right", "abcd\nefgh\nijkl", 0, 1, 2, 3,
+			[]caret{{1, 3}, {6, 8}, {11, 13}}, caret{11, 13},
+		},
+		{
+			"up and left", "abcd\nefgh\nijkl", 2, 3, 0, 1,
+			[]caret{{3, 1}, {8, 6}, {13, 11}}, caret{3, 1},
+		},
+		{
+			"short lines end at their end", "abcd\na\n\nabcd", 0, 2, 3, 4,
+			[]caret{{2, 4}, {6, 6}, {7, 7}, {10, 12}}, caret{10, 12},
+		},
+		{
+			"tabs count to the next stop", "\tab\nabcdef", 0, 4, 1, 6,
+			[]caret{{1, 3}, {8, 10}}, caret{8, 10},
+		},
+		{
+			"one row", "abcd\nefgh", 1, 3, 1, 1,
+			[]caret{{8, 6}}, caret{8, 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEditor()
+			e.Load(tt.text)
+			e.selectBlock(tt.fromRow, tt.fromVisual, tt.toRow, tt.toVisual)
+			if got := caretsOf(e); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cursors = %v, want %v", got, tt.want)
+			}
+			// The main cursor is on the row the block was dragged to.
+			if got := e.primary(); got != tt.primary {
+				t.Errorf("main cursor = %v, want %v", got, tt.primary)
+			}
+		})
+	}
+}
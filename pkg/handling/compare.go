@@ -0,0 +1,219 @@
+package handling
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxDiffCost caps how many lines or words a diff looks for the fewest changes
+// across. Past it, what is left over differs as a whole, which keeps comparing
+// very different texts quick.
+const maxDiffCost = 4096
+
+// Hunk is a run of differing lines: lines LeftStart up to LeftEnd of the left
+// text stand where lines RightStart up to RightEnd are in the right one. One
+// side is empty for lines only added or removed.
+type Hunk struct {
+	LeftStart, LeftEnd   int
+	RightStart, RightEnd int
+}
+
+// Span is a range of byte offsets into a line.
+type Span struct {
+	Start, End int
+}
+
+// DiffLines finds the hunks turning the left lines into the right ones, in order.
+func DiffLines(left, right []string) []Hunk {
+	return diffSequences(left, right)
+}
+
+// Merge copies the hunk's lines of the left text over its lines of the right
+// one, or the other way around when toRight is false, returning the lines of
+// the side merged into.
+func (h Hunk) Merge(left, right []string, toRight bool) []string {
+	if toRight {
+		return slices.Concat(right[:h.RightStart], left[h.LeftStart:h.LeftEnd], right[h.RightEnd:])
+	}
+	return slices.Concat(left[:h.LeftStart], right[h.RightStart:h.RightEnd], left[h.LeftEnd:])
+}
+
+// SplitLines splits text into the lines DiffLines compares.
+func SplitLines(text string) []string {
+	return strings.Split(text, "\n")
+}
+
+// DiffLine finds the words that differ between two versions of a line, as spans of each.
+func DiffLine(left, right string) ([]Span, []Span) {
+	leftWords, rightWords := splitWords(left), splitWords(right)
+	var leftSpans, rightSpans []Span
+	for _, h := range diffSequences(leftWords, rightWords) {
+		if span, ok := wordSpan(leftWords, h.LeftStart, h.LeftEnd); ok {
+			leftSpans = append(leftSpans, span)
+		}
+		if span, ok := wordSpan(rightWords, h.RightStart, h.RightEnd); ok {
+			rightSpans = append(rightSpans, span)
+		}
+	}
+	return leftSpans, rightSpans
+}
+
+// MapRow finds the row of the right text beside a row of the left one, or
+// the other way around when fromRight is set. Rows in a hunk map in proportion.
+func MapRow(hunks []Hunk, row float32, fromRight bool) float32 {
+	shift := float32(0)
+	for _, h := range hunks {
+		from, fromEnd, to, toEnd := h.LeftStart, h.LeftEnd, h.RightStart, h.RightEnd
+		if fromRight {
+			from, fromEnd, to, toEnd = to, toEnd, from, fromEnd
+		}
+		if row < float32(from) {
+			break
+		}
+		if row < float32(fromEnd) {
+			return float32(to) + (row-float32(from))*float32(toEnd-to)/float32(fromEnd-from)
+		}
+		shift = float32(toEnd - fromEnd)
+	}
+	return row + shift
+}
+
+// Split a line into words, runs of space and single other characters.
+func splitWords(line string) []string {
+	var words []string
+	for line != "" {
+		r, size := utf8.DecodeRuneInString(line)
+		end := size
+		switch {
+		case isWordRune(r):
+			end = runEnd(line, size, isWordRune)
+		case unicode.IsSpace(r):
+			end = runEnd(line, size, unicode.IsSpace)
+		}
+		words = append(words, line[:end])
+		line = line[end:]
+	}
+	return words
+}
+
+// The end of the run of runes in s from i that are all in.
+func runEnd(s string, i int, in func(rune) bool) int {
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !in(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// The byte span covering words start up to end, which is empty when they are.
+func wordSpan(words []string, start, end int) (Span, bool) {
+	if start == end {
+		return Span{}, false
+	}
+	offset := 0
+	for _, word := range words[:start] {
+		offset += len(word)
+	}
+	length := 0
+	for _, word := range words[start:end] {
+		length += len(word)
+	}
+	return Span{offset, offset + length}, true
+}
+
+// Find the fewest hunks turning a into b with Myers' algorithm, trimming what
+// they start and end with in common first.
+func diffSequences[T comparable](a, b []T) []Hunk {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	hunks := myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for i := range hunks {
+		hunks[i].LeftStart += prefix
+		hunks[i].LeftEnd += prefix
+		hunks[i].RightStart += prefix
+		hunks[i].RightEnd += prefix
+	}
+	return hunks
+}
+
+// Myers' diff of a and b, giving up past maxDiffCost.
+func myers[T comparable](a, b []T) []Hunk {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	if n == 0 || m == 0 {
+		return []Hunk{{0, n, 0, m}}
+	}
+
+	// v holds the furthest x along each diagonal k = x - y, offset by limit.
+	// trace keeps v[-d:d+1] after each round d to walk back through.
+	limit := min(n+m, maxDiffCost)
+	v := make([]int, 2*limit+2)
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[limit+k-1] < v[limit+k+1] {
+				x = v[limit+k+1]
+			} else {
+				x = v[limit+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[limit+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+		trace = append(trace, slices.Clone(v[limit-d:limit+d+1]))
+	}
+	return []Hunk{{0, n, 0, m}}
+}
+
+// Walk back from the end through the rounds of Myers' diff, collecting the hunks.
+func backtrack(trace [][]int, x, y int) []Hunk {
+	var hunks []Hunk
+	for d := len(trace); d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		var prevK int
+		if k == -d || k != d && prev[k-1+d-1] < prev[k+1+d-1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+
+		// The step from the previous round adds b[prevY] or removes a[prevX].
+		h := Hunk{prevX, prevX, prevY, prevY}
+		if prevK == k+1 {
+			h.RightEnd++
+		} else {
+			h.LeftEnd++
+		}
+		if n := len(hunks); n > 0 && hunks[n-1].LeftStart == h.LeftEnd && hunks[n-1].RightStart == h.RightEnd {
+			hunks[n-1].LeftStart, hunks[n-1].RightStart = h.LeftStart, h.RightStart
+		} else {
+			hunks = append(hunks, h)
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(hunks)
+	return hunks
+}
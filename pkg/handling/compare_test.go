@@ -0,0 +1,96 @@
+package handling
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		left, right string
+		want        []Hunk
+	}{
+		{"both empty", "", "", nil},
+		{"identical", "a\nb\nc", "a\nb\nc", nil},
+		{"all new", "", "a\nb", []Hunk{{0, 1, 0, 2}}},
+		{"all removed", "a\nb", "", []Hunk{{0, 2, 0, 1}}},
+		{"line added", "a\nc", "a\nb\nc", []Hunk{{1, 1, 1, 2}}},
+		{"line removed", "a\nb\nc", "a\nc", []Hunk{{1, 2, 1, 1}}},
+		{"line changed", "a\nb\nc", "a\nx\nc", []Hunk{{1, 2, 1, 2}}},
+		{"interleaved", "a\nb\nc\nd\ne", "a\nx\nc\ne\nf", []Hunk{{1, 2, 1, 2}, {3, 4, 3, 3}, {5, 5, 4, 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffLines(SplitLines(tt.left), SplitLines(tt.right))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DiffLines(%q, %q) = %v, want %v", tt.left, tt.right, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLine(t *testing.T) {
+	left, right := DiffLine("total := price * count", "total := price + count")
+	if want := []Span{{15, 16}}; !slices.Equal(left, want) || !slices.Equal(right, want) {
+		t.Errorf("DiffLine = %v, %v, want %v on both sides", left, right, want)
+	}
+	left, right = DiffLine("café au lait", "café noir")
+	if !slices.Equal(left, []Span{{6, 13}}) || !slices.Equal(right, []Span{{6, 10}}) {
+		t.Errorf("DiffLine = %v, %v, want the words after café", left, right)
+	}
+}
+
+// Texts to merge between, with hunks of every kind.
+var mergeTests = []struct {
+	name        string
+	left, right string
+}{
+	{"empty and full", "", "a\nb"},
+	{"identical", "a\nb", "a\nb"},
+	{"interleaved", "a\nb\nc\nd\ne", "a\nx\nc\ne\nf"},
+	{"ends differ", "x\nb\nc\ny", "b\nc"},
+	{"changes and moves", "one\ntwo\nthree\nfour\nfive", "zero\ntwo\nfour\nthree\nfive\nsix"},
+}
+
+func TestHunkMergeEachWay(t *testing.T) {
+	for _, tt := range mergeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := SplitLines(tt.left), SplitLines(tt.right)
+			// Merging every hunk, last first so the earlier ones stay put, gives the other side.
+			hunks := DiffLines(left, right)
+			toRight, toLeft := right, left
+			for i := len(hunks) - 1; i >= 0; i-- {
+				toRight = hunks[i].Merge(left, toRight, true)
+				toLeft = hunks[i].Merge(toLeft, right, false)
+			}
+			if got := strings.Join(toRight, "\n"); got != tt.left {
+				t.Errorf("merging into the right side gave %q, want %q", got, tt.left)
+			}
+			if got := strings.Join(toLeft, "\n"); got != tt.right {
+				t.Errorf("merging into the left side gave %q, want %q", got, tt.right)
+			}
+		})
+	}
+}
+
+func TestHunkMergeOneAtATime(t *testing.T) {
+	for _, tt := range mergeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			// As the compare window does: merge the first hunk and diff again, until none are left.
+			left, right := SplitLines(tt.left), SplitLines(tt.right)
+			for hunks := DiffLines(left, right); len(hunks) > 0; {
+				left = hunks[0].Merge(left, right, false)
+				next := DiffLines(left, right)
+				if len(next) >= len(hunks) {
+					t.Fatalf("merging %v left %v", hunks[0], next)
+				}
+				hunks = next
+			}
+			if got := strings.Join(left, "\n"); got != tt.right {
+				t.Errorf("merged left side = %q, want %q", got, tt.right)
+			}
+		})
+	}
+}
@@ -1,13 +1,649 @@
 package handling
 
-import "fyne.io/fyne/v2/widget"
+import (
+	"strings"
+	"unicode/utf8"
 
-// Editor wraps the text editor widget.
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/syntax"
+)
+
+// tabWidth is how many columns a tab advances to.
+const tabWidth = 4
+
+// Editor is Leda's text editing widget. It keeps its text in a Buffer and its
+// undo history in Leda, drawing only the lines in view.
 type Editor struct {
-	Widget *widget.Entry
+	widget.BaseWidget
+
+	// Buffer holds the text being edited.
+	Buffer *Buffer
+	// History records edits so they can be undone.
+	History *History
+	// Highlighter colors the text, when set.
+	Highlighter *syntax.Highlighter
+	// ReadOnly blocks typing, cutting, pasting and undo while still allowing navigation and copying.
+	ReadOnly bool
+	// CursorRow and CursorColumn place the cursor, counting lines and runes from zero.
+	CursorRow, CursorColumn int
+
+	// OnChanged is called after every edit.
+	OnChanged func()
+	// OnCursorChanged is called when the cursor moves.
+	OnCursorChanged func()
+	// OnEdited is called after the text changes, whichever view of the buffer changed it.
+	OnEdited func()
+	// OnScrolled is called when the view scrolls.
+	OnScrolled func()
+
+	// The selection runs from the anchor to the cursor while selecting.
+	anchorRow, anchorColumn int
+	selecting               bool
+	// goalColumn keeps the visual column when moving up and down past short lines.
+	goalColumn int
+	shift      bool
+	focused    bool
+	// carets are the cursors besides the main one, in no particular order.
+	carets []caret
+	// views holds every editor on the buffer, shared between them.
+	views *[]*Editor
+	// marks color parts of the text, and gutterMarks rows in the gutter.
+	marks       []Mark
+	gutterMarks []Mark
+	// pendingScroll brings the cursor into view once the editor has a size.
+	pendingScroll bool
+	lineNumbers   LineNumbers
+
+	scroll  *container.Scroll
+	content *editorContent
+	gutter  *editorGutter
+	// gutterClip keeps partly scrolled numbers inside the gutter.
+	gutterClip *container.Scroll
+	// gutterWidth is the width the gutter was last laid out at.
+	gutterWidth float32
 }
 
-// NewEditor creates a new markdown editor.
+// NewEditor creates an empty editor.
 func NewEditor() *Editor {
-	return &Editor{Widget: widget.NewMultiLineEntry()}
+	e := &Editor{Buffer: NewBuffer(""), History: NewHistory(), goalColumn: -1}
+	e.views = &[]*Editor{e}
+	e.content = &editorContent{editor: e}
+	e.content.ExtendBaseWidget(e.content)
+	e.scroll = container.NewScroll(e.content)
+	e.scroll.OnScrolled = func(fyne.Position) {
+		e.content.Refresh()
+		e.gutter.Refresh()
+		if e.OnScrolled != nil {
+			e.OnScrolled()
+		}
+	}
+	e.gutter = &editorGutter{editor: e}
+	e.gutter.ExtendBaseWidget(e.gutter)
+	e.gutterClip = container.NewScroll(e.gutter)
+	e.gutterClip.Direction = container.ScrollNone
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// Text returns the whole text.
+func (e *Editor) Text() string {
+	return e.Buffer.String()
+}
+
+// Load replaces the text and forgets the undo history.
+func (e *Editor) Load(text string) {
+	e.LoadBuffer(NewBuffer(text))
+}
+
+// LoadBuffer edits another buffer in every view, closing the old one and forgetting the
+// undo history and highlighting.
+func (e *Editor) LoadBuffer(buffer *Buffer) {
+	if err := e.Buffer.Close(); err != nil {
+		fyne.LogError("Failed to close file", err)
+	}
+	e.History.Clear()
+	for _, v := range *e.views {
+		v.Buffer = buffer
+		v.Highlighter = nil
+		v.CursorRow, v.CursorColumn, v.selecting = 0, 0, false
+		v.clearCarets()
+		v.content.textChanged()
+		v.gutter.Refresh()
+	}
+	e.changed()
+	e.edited()
+}
+
+// SetText replaces the text as a single undoable step.
+func (e *Editor) SetText(text string) {
+	edit, ok := diff(e.Text(), text)
+	if !ok {
+		return
+	}
+	e.History.Break()
+	e.clearCarets()
+	e.replace(edit.Pos, edit.Pos+len(edit.Deleted), edit.Inserted)
+	e.History.Break()
+}
+
+// Undo reverts the last step in the history.
+func (e *Editor) Undo() {
+	if e.ReadOnly {
+		return
+	}
+	if edits, ok := e.History.Undo(); ok {
+		e.applyEdits(edits, len(edits)-1)
+	}
+}
+
+// Redo re-applies the last undone step.
+func (e *Editor) Redo() {
+	if e.ReadOnly {
+		return
+	}
+	if edits, ok := e.History.Redo(); ok {
+		e.applyEdits(edits, len(edits)-1)
+	}
+}
+
+// Select highlights the text between byte offsets start and end, leaving a
+// single cursor at end.
+func (e *Editor) Select(start, end int) {
+	e.clearCarets()
+	e.selectMain(start, end)
+}
+
+// SelectedText returns the selected text, or "" when nothing is selected.
+func (e *Editor) SelectedText() string {
+	start, end, ok := e.selection()
+	if !ok {
+		return ""
+	}
+	return e.Buffer.Slice(start, end)
+}
+
+// GoTo moves a single cursor to a row and column, clamped to the text, and scrolls it into view.
+func (e *Editor) GoTo(row, col int) {
+	e.clearCarets()
+	e.CursorRow, e.CursorColumn = e.RowCol(e.Offset(row, col))
+	e.selecting = false
+	e.cursorMoved()
+}
+
+// RowCol converts a byte offset in the text into a cursor row and column.
+func (e *Editor) RowCol(pos int) (int, int) {
+	return e.Buffer.RowCol(pos)
+}
+
+// Offset converts a cursor row and column into a byte offset in the text.
+func (e *Editor) Offset(row, col int) int {
+	return e.Buffer.Offset(row, col)
+}
+
+// CursorOffset returns the cursor position as a byte offset in the text.
+func (e *Editor) CursorOffset() int {
+	return e.Offset(e.CursorRow, e.CursorColumn)
+}
+
+// ScrollToCursor scrolls the least distance that brings the cursor into view.
+func (e *Editor) ScrollToCursor() {
+	size := e.scroll.Size()
+	if size.IsZero() {
+		e.pendingScroll = true
+		return
+	}
+	e.pendingScroll = false
+
+	lineHeight, charWidth := e.content.metrics()
+	pad := e.content.padding()
+	line := e.Buffer.Line(e.CursorRow)
+	x := pad + float32(visualColumn(line, e.CursorColumn))*charWidth
+	y := float32(e.CursorRow) * lineHeight
+
+	offset := e.scroll.Offset
+	if y < offset.Y {
+		offset.Y = y
+	} else if y+lineHeight > offset.Y+size.Height {
+		offset.Y = y + lineHeight - size.Height
+	}
+	if x-pad < offset.X {
+		offset.X = max(0, x-pad)
+	} else if x+charWidth+pad > offset.X+size.Width {
+		offset.X = x + charWidth + pad - size.Width
+	}
+	if offset != e.scroll.Offset {
+		e.content.Resize(e.content.MinSize().Max(size))
+		e.scroll.Offset = offset
+		e.scroll.Refresh()
+	}
+}
+
+// FocusGained shows the cursor.
+func (e *Editor) FocusGained() {
+	e.focused = true
+	e.content.Refresh()
+}
+
+// FocusLost hides the cursor.
+func (e *Editor) FocusLost() {
+	e.focused = false
+	e.shift = false
+	e.content.Refresh()
+}
+
+// AcceptsTab keeps Tab for indenting instead of moving the focus.
+func (e *Editor) AcceptsTab() bool {
+	return true
+}
+
+// KeyDown tracks whether shift is held, for extending the selection.
+func (e *Editor) KeyDown(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shift = true
+	}
+}
+
+// KeyUp tracks whether shift is held, for extending the selection.
+func (e *Editor) KeyUp(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shift = false
+	}
+}
+
+// TypedRune inserts a typed character in place of the selection.
+func (e *Editor) TypedRune(r rune) {
+	if e.ReadOnly {
+		return
+	}
+	e.insert(string(r))
+}
+
+// TypedKey edits and moves the cursor.
+func (e *Editor) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyLeft:
+		e.moveCarets(func(c caret) int { return e.prevRune(c.head) })
+		e.moveTo(e.RowCol(e.prevRune(e.CursorOffset())))
+	case fyne.KeyRight:
+		e.moveCarets(func(c caret) int { return e.nextRune(c.head) })
+		e.moveTo(e.RowCol(e.nextRune(e.CursorOffset())))
+	case fyne.KeyUp:
+		e.moveCarets(func(c caret) int { return e.caretLines(c, -1) })
+		e.moveLines(-1)
+	case fyne.KeyDown:
+		e.moveCarets(func(c caret) int { return e.caretLines(c, 1) })
+		e.moveLines(1)
+	case fyne.KeyPageUp:
+		e.clearCarets()
+		e.moveLines(-e.pageRows())
+	case fyne.KeyPageDown:
+		e.clearCarets()
+		e.moveLines(e.pageRows())
+	case fyne.KeyHome:
+		e.moveCarets(func(c caret) int {
+			row, col := e.RowCol(c.head)
+			return e.Offset(row, e.lineHome(row, col))
+		})
+		e.moveTo(e.CursorRow, e.lineHome(e.CursorRow, e.CursorColumn))
+	case fyne.KeyEnd:
+		e.moveCarets(func(c caret) int {
+			row, _ := e.RowCol(c.head)
+			return e.Buffer.LineStart(row) + len(e.Buffer.Line(row))
+		})
+		e.moveTo(e.CursorRow, utf8.RuneCountInString(e.Buffer.Line(e.CursorRow)))
+	case fyne.KeyEscape:
+		if len(e.carets) > 0 {
+			e.clearCarets()
+			e.cursorMoved()
+		}
+	}
+
+	if e.ReadOnly {
+		return
+	}
+	switch key.Name {
+	case fyne.KeyBackspace:
+		e.erase(e.prevRune)
+	case fyne.KeyDelete:
+		e.erase(e.nextRune)
+	case fyne.KeyReturn, fyne.KeyEnter:
+		// Keep the indentation of each cursor's line.
+		e.insertEach(func(_ int, c caret) string {
+			start, _ := c.ordered()
+			row, _ := e.RowCol(start)
+			before := e.Buffer.Slice(e.Buffer.LineStart(row), start)
+			return "\n" + before[:len(before)-len(strings.TrimLeft(before, " \t"))]
+		})
+	case fyne.KeyTab:
+		e.insert("\t")
+	}
+}
+
+// TypedShortcut handles the clipboard, undo and word and document movement,
+// passing other shortcuts on to the window.
+func (e *Editor) TypedShortcut(shortcut fyne.Shortcut) {
+	switch s := shortcut.(type) {
+	case *fyne.ShortcutCopy:
+		if text := e.selectedTexts(); text != "" {
+			s.Clipboard.SetContent(text)
+		}
+		return
+	case *fyne.ShortcutSelectAll:
+		e.Select(0, e.Buffer.Len())
+		return
+	case *fyne.ShortcutCut:
+		if text := e.selectedTexts(); text != "" && !e.ReadOnly {
+			s.Clipboard.SetContent(text)
+			e.insert("")
+		}
+		return
+	case *fyne.ShortcutPaste:
+		if !e.ReadOnly {
+			e.History.Break()
+			e.paste(s.Clipboard.Content())
+			e.History.Break()
+		}
+		return
+	case *fyne.ShortcutUndo:
+		e.Undo()
+		return
+	case *fyne.ShortcutRedo:
+		e.Redo()
+		return
+	case *desktop.CustomShortcut:
+		if e.typedCustomShortcut(s) {
+			return
+		}
+	}
+
+	if c := fyne.CurrentApp().Driver().CanvasForObject(e); c != nil {
+		if handler, ok := c.(fyne.Shortcutable); ok {
+			handler.TypedShortcut(shortcut)
+		}
+	}
+}
+
+// Handle Ctrl with the arrow, Home, End and delete keys, with Shift extending the selection.
+func (e *Editor) typedCustomShortcut(s *desktop.CustomShortcut) bool {
+	modifier := s.Modifier &^ fyne.KeyModifierShift
+	if modifier != fyne.KeyModifierShortcutDefault && modifier != fyne.KeyModifierControl {
+		return false
+	}
+	shift := e.shift
+	e.shift = s.Modifier&fyne.KeyModifierShift != 0
+	defer func() { e.shift = shift }()
+
+	switch s.KeyName {
+	case fyne.KeyZ:
+		if e.shift {
+			e.Redo()
+			return true
+		}
+	case fyne.KeyLeft:
+		e.moveCarets(func(c caret) int { return e.prevWord(c.head) })
+		e.moveTo(e.RowCol(e.prevWord(e.CursorOffset())))
+		return true
+	case fyne.KeyRight:
+		e.moveCarets(func(c caret) int { return e.nextWord(c.head) })
+		e.moveTo(e.RowCol(e.nextWord(e.CursorOffset())))
+		return true
+	case fyne.KeyHome:
+		e.clearCarets()
+		e.moveTo(0, 0)
+		return true
+	case fyne.KeyEnd:
+		e.clearCarets()
+		e.moveTo(e.RowCol(e.Buffer.Len()))
+		return true
+	case fyne.KeyBackspace:
+		if !e.ReadOnly {
+			e.erase(e.prevWord)
+		}
+		return true
+	case fyne.KeyDelete:
+		if !e.ReadOnly {
+			e.erase(e.nextWord)
+		}
+		return true
+	}
+	return false
+}
+
+// Swap the text between start and end for text with a single cursor, and record the edit.
+func (e *Editor) replace(start, end int, text string) {
+	edit := Edit{Pos: start, Deleted: e.Buffer.Slice(start, end), Inserted: text}
+	e.History.Add(edit)
+	e.applyEdits([]Edit{edit}, 0)
+}
+
+// Tell every view's listener about new text.
+func (e *Editor) edited() {
+	for _, v := range *e.views {
+		if v.OnEdited != nil {
+			v.OnEdited()
+		}
+	}
+}
+
+// Tell listeners about new text and redraw.
+func (e *Editor) changed() {
+	if e.OnChanged != nil {
+		e.OnChanged()
+	}
+	e.cursorMoved()
+}
+
+// Redraw the cursors and bring the main one into view.
+func (e *Editor) cursorMoved() {
+	e.mergeCarets()
+	e.content.Refresh()
+	e.gutter.Refresh()
+	e.ScrollToCursor()
+	if e.OnCursorChanged != nil {
+		e.OnCursorChanged()
+	}
+}
+
+// Move the cursor, extending the selection while shift is held.
+func (e *Editor) moveTo(row, col int) {
+	if e.shift && !e.selecting {
+		e.anchorRow, e.anchorColumn = e.CursorRow, e.CursorColumn
+		e.selecting = true
+	} else if !e.shift {
+		e.selecting = false
+	}
+	e.CursorRow, e.CursorColumn = row, col
+	e.goalColumn = -1
+	e.cursorMoved()
+}
+
+// Move the cursor up or down by rows, keeping to the visual column it started from.
+func (e *Editor) moveLines(rows int) {
+	goal := e.goalColumn
+	if goal < 0 {
+		goal = visualColumn(e.Buffer.Line(e.CursorRow), e.CursorColumn)
+	}
+	row := max(0, min(e.CursorRow+rows, e.Buffer.Lines()-1))
+	e.moveTo(row, runeColumn(e.Buffer.Line(row), goal))
+	e.goalColumn = goal
+}
+
+// Column Home moves to from col on a row: the first non-blank character, or the start of the line when already there.
+func (e *Editor) lineHome(row, col int) int {
+	line := e.Buffer.Line(row)
+	indent := utf8.RuneCountInString(line) - utf8.RuneCountInString(strings.TrimLeft(line, " \t"))
+	if col == indent {
+		return 0
+	}
+	return indent
+}
+
+// The number of whole lines in view.
+func (e *Editor) pageRows() int {
+	lineHeight, _ := e.content.metrics()
+	return max(1, int(e.scroll.Size().Height/lineHeight)-1)
+}
+
+// The ordered offsets of the selection, if any text is selected.
+func (e *Editor) selection() (int, int, bool) {
+	if !e.selecting {
+		return 0, 0, false
+	}
+	start, end := e.Offset(e.anchorRow, e.anchorColumn), e.CursorOffset()
+	if start > end {
+		start, end = end, start
+	}
+	return start, end, start != end
+}
+
+// The offset of the rune before pos.
+func (e *Editor) prevRune(pos int) int {
+	if pos == 0 {
+		return 0
+	}
+	text := e.Buffer.Slice(max(0, pos-utf8.UTFMax), pos)
+	_, size := utf8.DecodeLastRuneInString(text)
+	return pos - size
+}
+
+// The offset of the rune after pos.
+func (e *Editor) nextRune(pos int) int {
+	if pos >= e.Buffer.Len() {
+		return pos
+	}
+	_, size := utf8.DecodeRuneInString(e.Buffer.Slice(pos, min(e.Buffer.Len(), pos+utf8.UTFMax)))
+	return pos + size
+}
+
+// The start of the word before pos, skipping any spaces and punctuation first.
+func (e *Editor) prevWord(pos int) int {
+	row, _ := e.RowCol(pos)
+	start := e.Buffer.LineStart(row)
+	if pos == start {
+		return e.prevRune(pos)
+	}
+	line := e.Buffer.Slice(start, pos)
+	i := len(line)
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(line[:i])
+		if isWordRune(r) {
+			break
+		}
+		i -= size
+	}
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(line[:i])
+		if !isWordRune(r) {
+			break
+		}
+		i -= size
+	}
+	return start + i
+}
+
+// The end of the word after pos, skipping any spaces and punctuation first.
+func (e *Editor) nextWord(pos int) int {
+	row, _ := e.RowCol(pos)
+	end := e.Buffer.LineStart(row) + len(e.Buffer.Line(row))
+	if pos == end {
+		return e.nextRune(pos)
+	}
+	line := e.Buffer.Slice(pos, end)
+	i := 0
+	for i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if isWordRune(r) {
+			break
+		}
+		i += size
+	}
+	for i < len(line) {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if !isWordRune(r) {
+			break
+		}
+		i += size
+	}
+	return pos + i
+}
+
+// SelectWord selects the word at pos.
+func (e *Editor) SelectWord(pos int) {
+	row, _ := e.RowCol(pos)
+	start := e.Buffer.LineStart(row)
+	line := e.Buffer.Line(row)
+	from, to := pos-start, pos-start
+	for from > 0 {
+		r, size := utf8.DecodeLastRuneInString(line[:from])
+		if !isWordRune(r) {
+			break
+		}
+		from -= size
+	}
+	for to < len(line) {
+		r, size := utf8.DecodeRuneInString(line[to:])
+		if !isWordRune(r) {
+			break
+		}
+		to += size
+	}
+	e.Select(start+from, start+to)
+}
+
+// Show the edit menu at a position on the canvas.
+func (e *Editor) showMenu(pos fyne.Position) {
+	c := fyne.CurrentApp().Driver().CanvasForObject(e)
+	windows := fyne.CurrentApp().Driver().AllWindows()
+	if c == nil || len(windows) == 0 {
+		return
+	}
+	clipboard := windows[0].Clipboard()
+
+	cut := fyne.NewMenuItem("Cut", func() { e.TypedShortcut(&fyne.ShortcutCut{Clipboard: clipboard}) })
+	copyItem := fyne.NewMenuItem("Copy", func() { e.TypedShortcut(&fyne.ShortcutCopy{Clipboard: clipboard}) })
+	paste := fyne.NewMenuItem("Paste", func() { e.TypedShortcut(&fyne.ShortcutPaste{Clipboard: clipboard}) })
+	selectAll := fyne.NewMenuItem("Select All", func() { e.TypedShortcut(&fyne.ShortcutSelectAll{}) })
+	cut.Disabled = e.ReadOnly
+	paste.Disabled = e.ReadOnly
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", cut, copyItem, paste, selectAll), c, pos)
+}
+
+// The visual column of a rune column, with tabs expanded.
+func visualColumn(line string, col int) int {
+	visual := 0
+	for _, r := range line {
+		if col == 0 {
+			break
+		}
+		col--
+		if r == '\t' {
+			visual += tabWidth - visual%tabWidth
+		} else {
+			visual++
+		}
+	}
+	return visual
+}
+
+// The rune column nearest a visual column.
+func runeColumn(line string, visual int) int {
+	col, at := 0, 0
+	for _, r := range line {
+		next := at + 1
+		if r == '\t' {
+			next = at + tabWidth - at%tabWidth
+		}
+		if visual < next && visual-at <= next-visual {
+			return col
+		}
+		if visual < next {
+			return col + 1
+		}
+		at = next
+		col++
+	}
+	return col
 }
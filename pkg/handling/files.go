@@ -2,14 +2,23 @@ package handling
 
 import (
 	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/widget"
+	"fyne.io/fyne/v2/storage"
 )
 
-// opens a file dialog and loads the selected file's content into the editor.
-func OpenFile(window fyne.Window, editor *widget.Entry) {
+// LargeFileSize is the size from which files open in large-file mode, paged in
+// from disk as they are viewed.
+const LargeFileSize = 8 << 20
+
+// opens a file dialog and hands the selected file's location and content to onOpen,
+// or just its location to onLarge when it is a large file.
+func OpenFile(window fyne.Window, onOpen func(uri fyne.URI, content string), onLarge func(uri fyne.URI)) {
 	dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
 		if err != nil {
 			dialog.ShowError(err, window)
@@ -20,18 +29,77 @@ func OpenFile(window fyne.Window, editor *widget.Entry) {
 		}
 		defer reader.Close()
 
+		if IsLargeFile(reader.URI()) {
+			onLarge(reader.URI())
+			return
+		}
+
 		data, err := io.ReadAll(reader)
 		if err != nil {
 			dialog.ShowError(err, window)
 			return
 		}
 
-		editor.SetText(string(data))
+		onOpen(reader.URI(), string(data))
 	}, window)
 }
 
-// opens a file dialog and saves the editor's content to the selected file.
-func SaveFile(window fyne.Window, editor *widget.Entry) {
+// writes content back to the file at uri.
+func SaveFile(uri fyne.URI, content string) error {
+	writer, err := storage.Writer(uri)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write([]byte(content)); err != nil { //converts to bytes and writes to file
+		writer.Close()
+		return err
+	}
+	// closing flushes the file, so its error means the content may not be there.
+	return writer.Close()
+}
+
+// writes a buffer to the file at uri. A file on disk is written to a new file
+// that then replaces the old one, so a failed write leaves the old one whole and
+// a paged buffer can go on reading from it until then.
+func SaveBuffer(uri fyne.URI, buffer *Buffer) error {
+	if uri.Scheme() != "file" {
+		writer, err := storage.Writer(uri)
+		if err != nil {
+			return err
+		}
+		if _, err := buffer.WriteTo(writer); err != nil {
+			writer.Close()
+			return err
+		}
+		return writer.Close()
+	}
+
+	// replace the file a link points to rather than the link.
+	path := uri.Path()
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if info, err := os.Stat(path); err == nil {
+		file.Chmod(info.Mode())
+	}
+	if _, err := buffer.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// opens a file dialog, saves content to the selected file and hands its location to onSaved.
+func SaveFileAs(window fyne.Window, content io.WriterTo, onSaved func(uri fyne.URI)) {
 	dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
 		if err != nil {
 			dialog.ShowError(err, window)
@@ -40,17 +108,104 @@ func SaveFile(window fyne.Window, editor *widget.Entry) {
 		if writer == nil {
 			return
 		}
-		defer writer.Close()
-
-		_, err = writer.Write([]byte(editor.Text)) //converts to bytes and writes to file
+		_, err = content.WriteTo(writer)
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
 		if err != nil {
 			dialog.ShowError(err, window)
 			return
 		}
+		onSaved(writer.URI())
 	}, window)
 }
 
-// clears the editor's content.
-func ClearEditor(editor *widget.Entry) {
-	editor.SetText("")
+// writes content to a file called name inside dir, creating dir when it is missing.
+func SaveFileInDir(dir fyne.URI, name, content string) (fyne.URI, error) {
+	if err := CreateDir(dir); err != nil {
+		return nil, err
+	}
+
+	uri, err := storage.Child(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	return uri, SaveFile(uri, content)
+}
+
+// creates dir along with any missing parent directories.
+func CreateDir(dir fyne.URI) error {
+	if exists, _ := storage.Exists(dir); exists {
+		return nil
+	}
+	if parent, err := storage.Parent(dir); err == nil {
+		if err := CreateDir(parent); err != nil {
+			return err
+		}
+	}
+	return storage.CreateListable(dir)
+}
+
+// reads the whole file at uri.
+func ReadFile(uri fyne.URI) (string, error) {
+	reader, err := storage.Reader(uri)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	return string(data), err
+}
+
+// reports whether uri is a local file big enough for large-file mode.
+func IsLargeFile(uri fyne.URI) bool {
+	if uri.Scheme() != "file" {
+		return false
+	}
+	info, err := os.Stat(uri.Path())
+	return err == nil && info.Mode().IsRegular() && info.Size() >= LargeFileSize
+}
+
+// opens the file at path as a buffer that pages in its text as needed.
+func OpenFileBuffer(path string) (*Buffer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	buffer, err := NewFileBuffer(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return buffer, nil
+}
+
+// lists a directory with sub-directories first, then files, each sorted by name.
+func ListDir(dir string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return strings.ToLower(entries[i].Name()) < strings.ToLower(entries[j].Name())
+	})
+	return entries, nil
+}
+
+// creates an empty file, failing if one already exists at path.
+func CreateFile(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return file.Close()
 }
@@ -0,0 +1,113 @@
+package handling
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxSearchFileSize is the largest file searched when looking through a folder.
+const DefaultMaxSearchFileSize = 1 << 20
+
+// binarySniffLength is how much of a file is checked for NUL bytes to spot binary content.
+const binarySniffLength = 8000
+
+// LineMatch is one match in a file along with the line it was found on.
+type LineMatch struct {
+	Match
+	// Line is the 1-based line number and Column the 0-based rune column of the match.
+	Line, Column int
+	// Preview is the text of the matching line.
+	Preview string
+}
+
+// FileResult groups the matches found in one file.
+type FileResult struct {
+	Path    string
+	Matches []LineMatch
+}
+
+// SearchFolder walks root looking for matcher in text files no bigger than maxSize,
+// skipping anything excluded by .gitignore files. It calls found for every file with
+// matches and stops early, returning the context's error, once ctx is cancelled.
+func SearchFolder(ctx context.Context, root string, matcher *Matcher, maxSize int64, found func(FileResult)) error {
+	ignore := &Ignore{}
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// Skip anything unreadable rather than abandoning the search.
+			return nil
+		}
+
+		if d.IsDir() {
+			if p != root && (d.Name() == ".git" || ignore.Match(p, true)) {
+				return filepath.SkipDir
+			}
+			ignore.Load(p)
+			return nil
+		}
+		if !d.Type().IsRegular() || ignore.Match(p, false) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() > maxSize {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil || !IsText(data) {
+			return nil
+		}
+
+		if matches := FindLines(string(data), matcher); len(matches) > 0 {
+			found(FileResult{Path: p, Matches: matches})
+		}
+		return nil
+	})
+}
+
+// FindLines returns every match of matcher in text with its line and column.
+func FindLines(text string, matcher *Matcher) []LineMatch {
+	var lines []LineMatch
+	line, lineStart := 1, 0
+	for _, match := range matcher.FindAll(text) {
+		// Advance line by line, as matches arrive in order.
+		for {
+			next := strings.IndexByte(text[lineStart:], '\n')
+			if next == -1 || lineStart+next >= match.Start {
+				break
+			}
+			lineStart += next + 1
+			line++
+		}
+
+		lineEnd := strings.IndexByte(text[lineStart:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += lineStart
+		}
+
+		lines = append(lines, LineMatch{
+			Match:   match,
+			Line:    line,
+			Column:  utf8.RuneCountInString(text[lineStart:match.Start]),
+			Preview: strings.TrimRight(text[lineStart:lineEnd], "\r"),
+		})
+	}
+	return lines
+}
+
+// IsText reports whether data looks like text rather than binary content.
+func IsText(data []byte) bool {
+	if len(data) > binarySniffLength {
+		data = data[:binarySniffLength]
+	}
+	return !bytes.Contains(data, []byte{0})
+}
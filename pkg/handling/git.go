@@ -0,0 +1,201 @@
+package handling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// FileState says how a file on disk differs from the last commit.
+type FileState int
+
+// File states, from the file's point of view in git.
+const (
+	// FileUnmodified matches the last commit.
+	FileUnmodified FileState = iota
+	// FileModified has changes not yet staged.
+	FileModified
+	// FileStaged has changes staged for the next commit and no others.
+	FileStaged
+	// FileAdded is staged but not in the last commit.
+	FileAdded
+	// FileUntracked is unknown to git.
+	FileUntracked
+)
+
+// String names the state for the status bar.
+func (s FileState) String() string {
+	switch s {
+	case FileModified:
+		return "Modified"
+	case FileStaged:
+		return "Staged"
+	case FileAdded:
+		return "Added"
+	case FileUntracked:
+		return "Untracked"
+	}
+	return "Unmodified"
+}
+
+// Blame says which commit last changed a line.
+type Blame struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Summary string
+}
+
+// Repository is a git repository found around a file, read without a git binary.
+type Repository struct {
+	// Root is the top folder of the work tree.
+	Root string
+
+	repo *git.Repository
+}
+
+// OpenRepository finds the git repository holding path, failing when there is none.
+func OpenRepository(path string) (*Repository, error) {
+	repo, err := git.PlainOpenWithOptions(filepath.Dir(path), &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	return &Repository{Root: worktree.Filesystem.Root(), repo: repo}, nil
+}
+
+// Branch names the checked out branch, or the commit when none is.
+func (r *Repository) Branch() string {
+	head, err := r.repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return ""
+	}
+	if head.Type() == plumbing.SymbolicReference {
+		return head.Target().Short()
+	}
+	return head.Hash().String()[:7]
+}
+
+// HeadText returns a file's text in the last commit, and false when it is not in it.
+func (r *Repository) HeadText(path string) (string, bool, error) {
+	file, err := r.headFile(path)
+	if file == nil || err != nil {
+		return "", false, err
+	}
+	text, err := file.Contents()
+	return text, err == nil, err
+}
+
+// State says how a file on disk differs from the last commit.
+func (r *Repository) State(path string) (FileState, error) {
+	name, err := r.relative(path)
+	if err != nil {
+		return FileUntracked, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FileUntracked, err
+	}
+	head, err := r.headFile(path)
+	if err != nil {
+		return FileUntracked, err
+	}
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		return FileUntracked, err
+	}
+	entry, err := idx.Entry(name)
+	if errors.Is(err, index.ErrEntryNotFound) {
+		if head == nil {
+			return FileUntracked, nil
+		}
+		// Removed from the index, but back on disk.
+		return FileModified, nil
+	} else if err != nil {
+		return FileUntracked, err
+	}
+
+	switch {
+	case entry.Hash != plumbing.ComputeHash(plumbing.BlobObject, data):
+		return FileModified, nil
+	case head == nil:
+		return FileAdded, nil
+	case entry.Hash != head.Hash:
+		return FileStaged, nil
+	}
+	return FileUnmodified, nil
+}
+
+// Blame finds the commit that last changed a row of a file as it was last committed.
+func (r *Repository) Blame(path string, row int) (Blame, error) {
+	name, err := r.relative(path)
+	if err != nil {
+		return Blame{}, err
+	}
+	commit, err := r.headCommit()
+	if commit == nil || err != nil {
+		return Blame{}, err
+	}
+	result, err := git.Blame(commit, name)
+	if err != nil {
+		return Blame{}, err
+	}
+	if row < 0 || row >= len(result.Lines) {
+		return Blame{}, fmt.Errorf("line %d is not in the last commit", row+1)
+	}
+
+	line := result.Lines[row]
+	blame := Blame{Hash: line.Hash.String()[:7], Author: line.AuthorName, Date: line.Date}
+	if c, err := r.repo.CommitObject(line.Hash); err == nil {
+		blame.Summary, _, _ = strings.Cut(c.Message, "\n")
+	}
+	return blame, nil
+}
+
+// The commit checked out, or nil before the first commit.
+func (r *Repository) headCommit() (*object.Commit, error) {
+	head, err := r.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return r.repo.CommitObject(head.Hash())
+}
+
+// A file as it was last committed, or nil when it was not.
+func (r *Repository) headFile(path string) (*object.File, error) {
+	name, err := r.relative(path)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.headCommit()
+	if commit == nil || err != nil {
+		return nil, err
+	}
+	file, err := commit.File(name)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	}
+	return file, err
+}
+
+// The path of a file in the repository, as git names it.
+func (r *Repository) relative(path string) (string, error) {
+	name, err := filepath.Rel(r.Root, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(name), nil
+}
@@ -0,0 +1,253 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// testRepo is a git repository in a temporary folder, changed through go-git.
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+	tree *git.Worktree
+}
+
+// Create an empty repository.
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testRepo{t: t, dir: dir, repo: repo, tree: tree}
+}
+
+// Write a file of the work tree, returning its path.
+func (r *testRepo) write(name, content string) string {
+	r.t.Helper()
+	path := filepath.Join(r.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+	return path
+}
+
+// Stage a file.
+func (r *testRepo) add(name string) {
+	r.t.Helper()
+	if _, err := r.tree.Add(name); err != nil {
+		r.t.Fatal(err)
+	}
+}
+
+// Write, stage and commit a file as author, returning the commit's short hash.
+func (r *testRepo) commit(name, content, author, message string) string {
+	r.t.Helper()
+	r.write(name, content)
+	r.add(name)
+	hash, err := r.tree.Commit(message, &git.CommitOptions{
+		Author: &object.Signature{Name: author, Email: author + "@example.com", When: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return hash.String()[:7]
+}
+
+// Open the repository as Leda does, from a file inside it.
+func (r *testRepo) open(name string) *Repository {
+	r.t.Helper()
+	repo, err := OpenRepository(filepath.Join(r.dir, filepath.FromSlash(name)))
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return repo
+}
+
+func TestRepositoryState(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("same.txt", "same\n", "ada", "first")
+	r.commit("modified.txt", "one\n", "ada", "second")
+	r.commit("staged.txt", "one\n", "ada", "third")
+	r.commit("staged-and-modified.txt", "one\n", "ada", "fourth")
+	r.commit("unstaged.txt", "one\n", "ada", "fifth")
+
+	r.write("modified.txt", "two\n")
+	r.write("staged.txt", "two\n")
+	r.add("staged.txt")
+	r.write("staged-and-modified.txt", "two\n")
+	r.add("staged-and-modified.txt")
+	r.write("staged-and-modified.txt", "three\n")
+	r.write("added.txt", "new\n")
+	r.add("added.txt")
+	r.write("untracked.txt", "new\n")
+	r.write("sub/untracked.txt", "new\n")
+
+	// Take a file out of the index while leaving it on disk, as "git rm --cached" does.
+	idx, err := r.repo.Storer.Index()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.Remove("unstaged.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.repo.Storer.SetIndex(idx); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := r.open("same.txt")
+	tests := []struct {
+		name string
+		want FileState
+	}{
+		{"same.txt", FileUnmodified},
+		{"modified.txt", FileModified},
+		{"staged.txt", FileStaged},
+		{"staged-and-modified.txt", FileModified},
+		{"added.txt", FileAdded},
+		{"untracked.txt", FileUntracked},
+		{"sub/untracked.txt", FileUntracked},
+		{"unstaged.txt", FileModified},
+	}
+	for _, tt := range tests {
+		got, err := repo.State(filepath.Join(r.dir, filepath.FromSlash(tt.name)))
+		if err != nil {
+			t.Errorf("State(%s): %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("State(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRepositoryStateBeforeFirstCommit(t *testing.T) {
+	r := newTestRepo(t)
+	r.write("added.txt", "new\n")
+	r.add("added.txt")
+	r.write("untracked.txt", "new\n")
+
+	repo := r.open("added.txt")
+	for name, want := range map[string]FileState{"added.txt": FileAdded, "untracked.txt": FileUntracked} {
+		if got, err := repo.State(filepath.Join(r.dir, name)); err != nil || got != want {
+			t.Errorf("State(%s) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if text, tracked, err := repo.HeadText(filepath.Join(r.dir, "added.txt")); err != nil || tracked || text != "" {
+		t.Errorf("HeadText before the first commit = %q, %v, %v", text, tracked, err)
+	}
+}
+
+func TestRepositoryHeadText(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("dir/file.txt", "committed\n", "ada", "first")
+	path := r.write("dir/file.txt", "changed\n")
+	r.write("new.txt", "new\n")
+
+	repo := r.open("dir/file.txt")
+	if repo.Root != r.dir {
+		t.Errorf("Root = %s, want %s", repo.Root, r.dir)
+	}
+	if text, tracked, err := repo.HeadText(path); err != nil || !tracked || text != "committed\n" {
+		t.Errorf("HeadText = %q, %v, %v, want the committed text", text, tracked, err)
+	}
+	if text, tracked, err := repo.HeadText(filepath.Join(r.dir, "new.txt")); err != nil || tracked || text != "" {
+		t.Errorf("HeadText of a new file = %q, %v, %v, want nothing", text, tracked, err)
+	}
+}
+
+func TestRepositoryBranch(t *testing.T) {
+	r := newTestRepo(t)
+	hash := r.commit("file.txt", "text\n", "ada", "first")
+	repo := r.open("file.txt")
+	if got := repo.Branch(); got != "master" {
+		t.Errorf("Branch = %q, want master", got)
+	}
+
+	// With no branch checked out, the commit is named instead.
+	head, err := r.repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.tree.Checkout(&git.CheckoutOptions{Hash: head.Hash()}); err != nil {
+		t.Fatal(err)
+	}
+	if got := repo.Branch(); got != hash {
+		t.Errorf("detached Branch = %q, want %q", got, hash)
+	}
+}
+
+func TestRepositoryBlame(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commit("file.txt", "one\ntwo\nthree\n", "ada", "Write three lines")
+	second := r.commit("file.txt", "one\nTWO\nthree\n", "grace", "Shout the second line\n\nWith a body.")
+	path := filepath.Join(r.dir, "file.txt")
+	repo := r.open("file.txt")
+
+	tests := []struct {
+		row     int
+		hash    string
+		author  string
+		summary string
+	}{
+		{0, first, "ada", "Write three lines"},
+		{1, second, "grace", "Shout the second line"},
+		{2, first, "ada", "Write three lines"},
+	}
+	for _, tt := range tests {
+		blame, err := repo.Blame(path, tt.row)
+		if err != nil {
+			t.Fatalf("Blame row %d: %v", tt.row, err)
+		}
+		if blame.Hash != tt.hash || blame.Author != tt.author || blame.Summary != tt.summary {
+			t.Errorf("Blame row %d = %+v, want %s by %s: %q", tt.row, blame, tt.hash, tt.author, tt.summary)
+		}
+	}
+	if _, err := repo.Blame(path, 10); err == nil {
+		t.Error("Blame past the last committed line succeeded")
+	}
+}
+
+func TestRepositoryBlameMapsEditedRows(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commit("file.txt", "one\ntwo\nthree", "ada", "first")
+	second := r.commit("file.txt", "one\ntwo\nTHREE", "grace", "second")
+	path := filepath.Join(r.dir, "file.txt")
+	repo := r.open("file.txt")
+	head, _, err := repo.HeadText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rows of the edited text map back to the committed rows, as showGitBlame does.
+	edited := "new\nnewer\none\ntwo\nTHREE"
+	hunks := DiffLines(SplitLines(head), SplitLines(edited))
+	for row, want := range map[int]string{2: first, 3: first, 4: second} {
+		blame, err := repo.Blame(path, int(MapRow(hunks, float32(row), true)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blame.Hash != want {
+			t.Errorf("edited row %d blamed on %s, want %s", row, blame.Hash, want)
+		}
+	}
+}
+
+func TestOpenRepositoryOutsideRepository(t *testing.T) {
+	if _, err := OpenRepository(filepath.Join(t.TempDir(), "file.txt")); err == nil {
+		t.Error("OpenRepository found a repository in an empty folder")
+	}
+}
@@ -0,0 +1,42 @@
+package handling
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseGoTo reads the target of Go To Line: "line", "line:column", or "+N" and
+// "-N" to move N lines from currentRow, which may also be followed by
+// ":column". Lines and columns are typed from 1; the returned row and column
+// count from zero.
+func ParseGoTo(input string, currentRow int) (int, int, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, 0, errors.New("enter a line number")
+	}
+
+	lineText, colText, hasCol := strings.Cut(input, ":")
+	col := 0
+	if hasCol {
+		n, err := strconv.Atoi(strings.TrimSpace(colText))
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("%q is not a column number", colText)
+		}
+		col = n - 1
+	}
+
+	lineText = strings.TrimSpace(lineText)
+	n, err := strconv.Atoi(lineText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a line number", lineText)
+	}
+	if strings.HasPrefix(lineText, "+") || strings.HasPrefix(lineText, "-") {
+		return max(0, currentRow+n), col, nil
+	}
+	if n < 1 {
+		return 0, 0, fmt.Errorf("%q is not a line number", lineText)
+	}
+	return n - 1, col, nil
+}
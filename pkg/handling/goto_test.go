@@ -0,0 +1,62 @@
+package handling
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestParseGoTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		current int
+		row     int
+		col     int
+	}{
+		{"line", "12", 0, 11, 0},
+		{"first line", "1", 5, 0, 0},
+		{"line and column", "12:5", 0, 11, 4},
+		{"spaces", "  12 : 5 ", 0, 11, 4},
+		{"forward", "+3", 10, 13, 0},
+		{"back", "-3", 10, 7, 0},
+		{"back with a column", "-3:2", 10, 7, 1},
+		{"no move", "+0", 10, 10, 0},
+		{"back past the top", "-20", 10, 0, 0},
+		// Lines past the end are left for the editor to clamp.
+		{"past the end", "1000000", 0, 999999, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row, col, err := ParseGoTo(tt.input, tt.current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if row != tt.row || col != tt.col {
+				t.Errorf("ParseGoTo(%q, %d) = %d, %d, want %d, %d", tt.input, tt.current, row, col, tt.row, tt.col)
+			}
+		})
+	}
+}
+
+func TestParseGoToInvalid(t *testing.T) {
+	for _, input := range []string{"", "  ", "abc", "0", "-", "+", "12:", "12:0", "12:x", "1.5", ":3"} {
+		if row, col, err := ParseGoTo(input, 10); err == nil {
+			t.Errorf("ParseGoTo(%q) = %d, %d, want an error", input, row, col)
+		}
+	}
+}
+
+func TestGoToClampsToText(t *testing.T) {
+	test.NewApp()
+	e := NewEditor()
+	e.Load("one\ntwo\nthree")
+	row, col, err := ParseGoTo("99:99", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.GoTo(row, col)
+	if e.CursorRow != 2 || e.CursorColumn != 5 {
+		t.Errorf("cursor at %d, %d, want the end of the last line", e.CursorRow, e.CursorColumn)
+	}
+}
@@ -0,0 +1,195 @@
+package handling
+
+import (
+	"image/color"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// LineNumbers picks how the gutter numbers lines.
+type LineNumbers int
+
+// Line numbering styles.
+const (
+	// LineNumbersAbsolute counts lines from the start of the text.
+	LineNumbersAbsolute LineNumbers = iota
+	// LineNumbersRelative counts lines away from the cursor, which shows its own number.
+	LineNumbersRelative
+	// LineNumbersHidden hides the gutter.
+	LineNumbersHidden
+)
+
+// SetLineNumbers changes how the gutter numbers lines.
+func (e *Editor) SetLineNumbers(style LineNumbers) {
+	e.lineNumbers = style
+	e.Refresh()
+}
+
+// editorGutter shows the line numbers beside the lines in view.
+type editorGutter struct {
+	widget.BaseWidget
+	editor *Editor
+}
+
+// Tapped selects the line that was clicked.
+func (g *editorGutter) Tapped(ev *fyne.PointEvent) {
+	e := g.editor
+	lineHeight, _ := e.content.metrics()
+	row := int((ev.Position.Y + e.scroll.Offset.Y) / lineHeight)
+	if row >= e.Buffer.Lines() {
+		return
+	}
+	if c := fyne.CurrentApp().Driver().CanvasForObject(e); c != nil {
+		c.Focus(e)
+	}
+	end := e.Buffer.Len()
+	if row+1 < e.Buffer.Lines() {
+		end = e.Buffer.LineStart(row + 1)
+	}
+	e.Select(e.Buffer.LineStart(row), end)
+}
+
+// gutterMarkWidth is how wide the strip of gutter marks is.
+const gutterMarkWidth = 4
+
+// The width of the gutter, wide enough for the last line number.
+func (g *editorGutter) width() float32 {
+	if g.editor.lineNumbers == LineNumbersHidden {
+		if len(g.editor.gutterMarks) > 0 {
+			return gutterMarkWidth
+		}
+		return 0
+	}
+	_, charWidth := g.editor.content.metrics()
+	digits := max(2, len(strconv.Itoa(g.editor.Buffer.Lines())))
+	return float32(digits)*charWidth + 2*g.editor.content.padding()
+}
+
+// CreateRenderer draws the numbers of the lines in view.
+func (g *editorGutter) CreateRenderer() fyne.WidgetRenderer {
+	r := &gutterRenderer{
+		gutter:     g,
+		background: canvas.NewRectangle(color.Transparent),
+		current:    canvas.NewRectangle(color.Transparent),
+	}
+	r.build()
+	return r
+}
+
+// gutterRenderer keeps a pool of the numbers in view.
+type gutterRenderer struct {
+	gutter     *editorGutter
+	background *canvas.Rectangle
+	current    *canvas.Rectangle
+	numbers    []*canvas.Text
+	marks      []*canvas.Rectangle
+	objects    []fyne.CanvasObject
+}
+
+func (r *gutterRenderer) Layout(fyne.Size) {
+	r.build()
+}
+
+func (r *gutterRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(r.gutter.width(), 0)
+}
+
+func (r *gutterRenderer) Refresh() {
+	r.build()
+	canvas.Refresh(r.gutter)
+}
+
+func (r *gutterRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *gutterRenderer) Destroy() {}
+
+// Position the numbers of the lines in view, following the editor's scrolling.
+func (r *gutterRenderer) build() {
+	e := r.gutter.editor
+	th := e.Theme()
+	variant := fyne.CurrentApp().Settings().ThemeVariant()
+	lineHeight, _ := e.content.metrics()
+	pad := e.content.padding()
+	size := r.gutter.Size()
+
+	r.objects = append(r.objects[:0], r.background)
+	r.background.FillColor = th.Color(theme.ColorNameInputBackground, variant)
+	r.background.Resize(size)
+
+	top := e.scroll.Offset.Y
+	first := max(0, int(top/lineHeight))
+	last := min(e.Buffer.Lines()-1, int((top+size.Height)/lineHeight))
+
+	if e.CursorRow >= first && e.CursorRow <= last {
+		r.current.FillColor = th.Color(theme.ColorNameHover, variant)
+		r.current.Move(fyne.NewPos(0, float32(e.CursorRow)*lineHeight-top))
+		r.current.Resize(fyne.NewSize(size.Width, lineHeight))
+		r.objects = append(r.objects, r.current)
+	}
+
+	r.buildMarks(first, last)
+	if e.lineNumbers == LineNumbersHidden {
+		return
+	}
+
+	for i, row := 0, first; row <= last; i, row = i+1, row+1 {
+		if i == len(r.numbers) {
+			text := canvas.NewText("", color.Transparent)
+			text.Alignment = fyne.TextAlignTrailing
+			text.TextStyle = fyne.TextStyle{Monospace: true}
+			r.numbers = append(r.numbers, text)
+		}
+		text := r.numbers[i]
+		number := row + 1
+		if e.lineNumbers == LineNumbersRelative && row != e.CursorRow {
+			number = max(row-e.CursorRow, e.CursorRow-row)
+		}
+		text.Text = strconv.Itoa(number)
+		text.TextSize = th.Size(theme.SizeNameText)
+		if row == e.CursorRow {
+			text.Color = th.Color(theme.ColorNameForeground, variant)
+		} else {
+			text.Color = th.Color(theme.ColorNamePlaceHolder, variant)
+		}
+		text.Move(fyne.NewPos(0, float32(row)*lineHeight-top))
+		text.Resize(fyne.NewSize(size.Width-pad, lineHeight))
+		r.objects = append(r.objects, text)
+	}
+}
+
+// Draw the gutter marks on the rows in view, as a strip at the right edge.
+func (r *gutterRenderer) buildMarks(first, last int) {
+	e := r.gutter.editor
+	lineHeight, _ := e.content.metrics()
+	top := e.scroll.Offset.Y
+	x := r.gutter.Size().Width - gutterMarkWidth
+	count := 0
+	for _, mark := range e.gutterMarks {
+		startRow, _ := e.RowCol(mark.Start)
+		endRow, _ := e.RowCol(mark.End)
+		if endRow < first || startRow > last {
+			continue
+		}
+		if count == len(r.marks) {
+			r.marks = append(r.marks, canvas.NewRectangle(color.Transparent))
+		}
+		rect := r.marks[count]
+		count++
+		rect.FillColor = mark.Color
+		if mark.Start == mark.End {
+			// Lines removed from here show as a notch between rows.
+			rect.Move(fyne.NewPos(x-gutterMarkWidth, float32(startRow)*lineHeight-top-gutterMarkWidth/2))
+			rect.Resize(fyne.NewSize(2*gutterMarkWidth, gutterMarkWidth))
+		} else {
+			rect.Move(fyne.NewPos(x, float32(startRow)*lineHeight-top))
+			rect.Resize(fyne.NewSize(gutterMarkWidth, float32(endRow-startRow+1)*lineHeight))
+		}
+		r.objects = append(r.objects, rect)
+	}
+}
@@ -0,0 +1,201 @@
+package handling
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// groupPause is how long typing may pause before a new undo step starts.
+const groupPause = time.Second
+
+// maxHistory limits how many undo steps are kept.
+const maxHistory = 1000
+
+// Edit records that Deleted was replaced by Inserted at byte offset Pos.
+type Edit struct {
+	Pos      int
+	Deleted  string
+	Inserted string
+
+	at time.Time
+}
+
+// History is an undo/redo stack of edits to a text buffer. Each step holds
+// the edits it made in the order they were made, so that an edit at several
+// cursors undoes at once.
+type History struct {
+	undo [][]Edit
+	redo [][]Edit
+	// closed stops the next edit merging into the last undo step.
+	closed bool
+}
+
+// NewHistory creates an empty history.
+func NewHistory() *History {
+	return &History{}
+}
+
+// Add records edits as one step, grouping a single edit with the last step
+// when it continues typing or deleting the same word.
+func (h *History) Add(edits ...Edit) {
+	step := make([]Edit, 0, len(edits))
+	now := time.Now()
+	for _, edit := range edits {
+		if edit.Deleted != edit.Inserted {
+			edit.at = now
+			step = append(step, edit)
+		}
+	}
+	if len(step) == 0 {
+		return
+	}
+	h.redo = nil
+
+	if !h.closed && len(step) == 1 && len(h.undo) > 0 {
+		last := h.undo[len(h.undo)-1]
+		if len(last) == 1 && last[0].merge(&step[0]) {
+			return
+		}
+	}
+	h.undo = append(h.undo, step)
+	if len(h.undo) > maxHistory {
+		h.undo = h.undo[1:]
+	}
+	h.closed = false
+}
+
+// Break ends the current undo step so the next edit starts a new one.
+func (h *History) Break() {
+	h.closed = true
+}
+
+// Undo takes back the last step, returning the edits that revert it in the
+// order to make them.
+func (h *History) Undo() ([]Edit, bool) {
+	if len(h.undo) == 0 {
+		return nil, false
+	}
+	step := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, step)
+	h.closed = true
+
+	inverse := make([]Edit, len(step))
+	for i, edit := range step {
+		inverse[len(step)-1-i] = Edit{Pos: edit.Pos, Deleted: edit.Inserted, Inserted: edit.Deleted}
+	}
+	return inverse, true
+}
+
+// Redo takes the last undone step again, returning the edits that re-apply it.
+func (h *History) Redo() ([]Edit, bool) {
+	if len(h.redo) == 0 {
+		return nil, false
+	}
+	step := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, step)
+	h.closed = true
+	return step, true
+}
+
+// CanUndo reports whether there is a step to undo.
+func (h *History) CanUndo() bool {
+	return len(h.undo) > 0
+}
+
+// CanRedo reports whether there is a step to redo.
+func (h *History) CanRedo() bool {
+	return len(h.redo) > 0
+}
+
+// Clear forgets all steps, e.g. after loading a new file.
+func (h *History) Clear() {
+	h.undo = nil
+	h.redo = nil
+	h.closed = false
+}
+
+// Try to fold next into this edit, as when typing or deleting one character at a time.
+func (e *Edit) merge(next *Edit) bool {
+	if next.at.Sub(e.at) > groupPause {
+		return false
+	}
+
+	switch {
+	case e.Deleted == "" && next.Deleted == "":
+		// Typing: extend the insertion until a new word starts.
+		if next.Pos != e.Pos+len(e.Inserted) || startsWord(e.Inserted, next.Inserted) {
+			return false
+		}
+		e.Inserted += next.Inserted
+	case e.Inserted == "" && next.Inserted == "":
+		if next.Pos+len(next.Deleted) == e.Pos {
+			// Backspace.
+			if startsWord(next.Deleted, e.Deleted) {
+				return false
+			}
+			e.Pos = next.Pos
+			e.Deleted = next.Deleted + e.Deleted
+		} else if next.Pos == e.Pos {
+			// Forward delete.
+			if startsWord(e.Deleted, next.Deleted) {
+				return false
+			}
+			e.Deleted += next.Deleted
+		} else {
+			return false
+		}
+	default:
+		return false
+	}
+
+	e.at = next.at
+	return true
+}
+
+// Report whether next begins a new word after prev, or contains a line break.
+func startsWord(prev, next string) bool {
+	if strings.Contains(next, "\n") {
+		return true
+	}
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	first, _ := utf8.DecodeRuneInString(next)
+	return isWordRune(first) && !isWordRune(last)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Find the single edit turning before into after, by trimming their common prefix and suffix.
+func diff(before, after string) (Edit, bool) {
+	if before == after {
+		return Edit{}, false
+	}
+
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+	for prefix > 0 && prefix < len(before) && !utf8.RuneStart(before[prefix]) {
+		prefix--
+	}
+
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !utf8.RuneStart(before[len(before)-suffix]) {
+		suffix--
+	}
+
+	return Edit{
+		Pos:      prefix,
+		Deleted:  before[prefix : len(before)-suffix],
+		Inserted: after[prefix : len(after)-suffix],
+	}, true
+}
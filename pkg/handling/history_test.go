@@ -0,0 +1,248 @@
+package handling
+
+import (
+	"testing"
+	"time"
+)
+
+// Make edits to text in order.
+func applyAll(text string, edits []Edit) string {
+	for _, edit := range edits {
+		text = text[:edit.Pos] + edit.Inserted + text[edit.Pos+len(edit.Deleted):]
+	}
+	return text
+}
+
+// Type text one character at a time at pos, as a step each.
+func typeText(h *History, pos int, text string) {
+	for _, r := range text {
+		h.Add(Edit{Pos: pos, Inserted: string(r)})
+		pos += len(string(r))
+	}
+}
+
+func TestHistoryGroupsTypingByWord(t *testing.T) {
+	h := NewHistory()
+	typeText(h, 0, "hello world")
+
+	if got := len(h.undo); got != 2 {
+		t.Fatalf("typing two words made %d steps, want 2", got)
+	}
+	// The space ends the first word rather than starting the second.
+	if got := h.undo[0][0].Inserted; got != "hello " {
+		t.Errorf("first step inserted %q, want %q", got, "hello ")
+	}
+	if got := h.undo[1][0].Inserted; got != "world" {
+		t.Errorf("second step inserted %q, want %q", got, "world")
+	}
+}
+
+func TestHistoryLineBreakStartsStep(t *testing.T) {
+	h := NewHistory()
+	typeText(h, 0, "ab\ncd")
+
+	if got := len(h.undo); got != 3 {
+		t.Errorf("typing across a line break made %d steps, want 3", got)
+	}
+}
+
+func TestHistoryPauseStartsStep(t *testing.T) {
+	h := NewHistory()
+	h.Add(Edit{Pos: 0, Inserted: "a"})
+	h.undo[0][0].at = time.Now().Add(-2 * groupPause)
+	h.Add(Edit{Pos: 1, Inserted: "b"})
+
+	if got := len(h.undo); got != 2 {
+		t.Errorf("typing after a pause made %d steps, want 2", got)
+	}
+}
+
+func TestHistoryBreak(t *testing.T) {
+	h := NewHistory()
+	h.Add(Edit{Pos: 0, Inserted: "a"})
+	h.Break()
+	h.Add(Edit{Pos: 1, Inserted: "b"})
+
+	if got := len(h.undo); got != 2 {
+		t.Errorf("typing after Break made %d steps, want 2", got)
+	}
+}
+
+func TestHistoryNonAdjacentTypingStartsStep(t *testing.T) {
+	h := NewHistory()
+	h.Add(Edit{Pos: 0, Inserted: "a"})
+	h.Add(Edit{Pos: 5, Inserted: "b"})
+
+	if got := len(h.undo); got != 2 {
+		t.Errorf("typing elsewhere made %d steps, want 2", got)
+	}
+}
+
+func TestHistoryBackspaceRun(t *testing.T) {
+	text := "one two"
+	h := NewHistory()
+	// Backspace from the end, one character at a time.
+	for pos := len(text); pos > 0; pos-- {
+		h.Add(Edit{Pos: pos - 1, Deleted: text[pos-1 : pos]})
+	}
+
+	if got := len(h.undo); got != 2 {
+		t.Fatalf("backspacing two words made %d steps, want 2", got)
+	}
+	if got := h.undo[0][0]; got.Pos != 4 || got.Deleted != "two" {
+		t.Errorf("first step deleted %q at %d, want %q at 4", got.Deleted, got.Pos, "two")
+	}
+	if got := h.undo[1][0]; got.Pos != 0 || got.Deleted != "one " {
+		t.Errorf("second step deleted %q at %d, want %q at 0", got.Deleted, got.Pos, "one ")
+	}
+}
+
+func TestHistoryForwardDeleteRun(t *testing.T) {
+	text := "one two"
+	h := NewHistory()
+	// Delete forward from the start, one character at a time.
+	for i := range text {
+		h.Add(Edit{Pos: 0, Deleted: text[i : i+1]})
+	}
+
+	if got := len(h.undo); got != 2 {
+		t.Fatalf("deleting two words made %d steps, want 2", got)
+	}
+	if got := h.undo[0][0].Deleted; got != "one " {
+		t.Errorf("first step deleted %q, want %q", got, "one ")
+	}
+	if got := h.undo[1][0].Deleted; got != "two" {
+		t.Errorf("second step deleted %q, want %q", got, "two")
+	}
+}
+
+func TestHistoryUndoRedo(t *testing.T) {
+	h := NewHistory()
+	text := ""
+	for _, edit := range []Edit{
+		{Pos: 0, Inserted: "hello"},
+		{Pos: 5, Inserted: " world"},
+		{Pos: 0, Deleted: "hello", Inserted: "goodbye"},
+	} {
+		h.Add(edit)
+		h.Break()
+		text = applyAll(text, []Edit{edit})
+	}
+
+	want := []string{"hello world", "hello", ""}
+	for _, w := range want {
+		edits, ok := h.Undo()
+		if !ok {
+			t.Fatal("Undo found nothing to undo")
+		}
+		text = applyAll(text, edits)
+		if text != w {
+			t.Errorf("after undo got %q, want %q", text, w)
+		}
+	}
+	if _, ok := h.Undo(); ok {
+		t.Error("Undo undid past the first step")
+	}
+
+	for _, w := range []string{"hello", "hello world", "goodbye world"} {
+		edits, ok := h.Redo()
+		if !ok {
+			t.Fatal("Redo found nothing to redo")
+		}
+		text = applyAll(text, edits)
+		if text != w {
+			t.Errorf("after redo got %q, want %q", text, w)
+		}
+	}
+	if h.CanRedo() {
+		t.Error("CanRedo after redoing every step")
+	}
+}
+
+func TestHistoryNewEditClearsRedo(t *testing.T) {
+	h := NewHistory()
+	h.Add(Edit{Pos: 0, Inserted: "a"})
+	h.Undo()
+	h.Add(Edit{Pos: 0, Inserted: "b"})
+
+	if h.CanRedo() {
+		t.Error("CanRedo after a new edit")
+	}
+}
+
+func TestHistoryMultiCursorStep(t *testing.T) {
+	// Typing "x" at three cursors, each edit made after the ones before it.
+	before := "a\nb\nc"
+	edits := []Edit{
+		{Pos: 0, Inserted: "x"},
+		{Pos: 3, Inserted: "x"},
+		{Pos: 6, Inserted: "x"},
+	}
+	after := applyAll(before, edits)
+	if after != "xa\nxb\nxc" {
+		t.Fatalf("edits made %q", after)
+	}
+
+	h := NewHistory()
+	h.Add(edits...)
+	if got := len(h.undo); got != 1 {
+		t.Fatalf("an edit at three cursors made %d steps, want 1", got)
+	}
+
+	undo, _ := h.Undo()
+	if got := applyAll(after, undo); got != before {
+		t.Errorf("undo made %q, want %q", got, before)
+	}
+	redo, _ := h.Redo()
+	if got := applyAll(before, redo); got != after {
+		t.Errorf("redo made %q, want %q", got, after)
+	}
+}
+
+func TestHistoryMultiCursorStepDoesNotMerge(t *testing.T) {
+	h := NewHistory()
+	h.Add(Edit{Pos: 0, Inserted: "a"})
+	h.Add(Edit{Pos: 1, Inserted: "b"}, Edit{Pos: 5, Inserted: "b"})
+
+	if got := len(h.undo); got != 2 {
+		t.Errorf("typing at two cursors after one made %d steps, want 2", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          Edit
+	}{
+		{"insert", "hello world", "hello big world", Edit{Pos: 6, Inserted: "big "}},
+		{"delete", "hello big world", "hello world", Edit{Pos: 6, Deleted: "big "}},
+		{"replace", "abc", "axc", Edit{Pos: 1, Deleted: "b", Inserted: "x"}},
+		{"from empty", "", "abc", Edit{Pos: 0, Inserted: "abc"}},
+		{"to empty", "abc", "", Edit{Pos: 0, Deleted: "abc"}},
+		{"repeated", "aaa", "aaaa", Edit{Pos: 3, Inserted: "a"}},
+		// é and è share their first byte, and é and ĩ their last.
+		{"shared first byte", "café", "cafè", Edit{Pos: 3, Deleted: "é", Inserted: "è"}},
+		{"shared last byte", "aéb", "aĩb", Edit{Pos: 1, Deleted: "é", Inserted: "ĩ"}},
+		{"multi-byte insert", "ab", "a日本b", Edit{Pos: 1, Inserted: "日本"}},
+		{"multi-byte neighbours", "日本", "日x本", Edit{Pos: 3, Inserted: "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := diff(tt.before, tt.after)
+			if !ok {
+				t.Fatal("diff found no change")
+			}
+			if got != tt.want {
+				t.Errorf("diff(%q, %q) = %+v, want %+v", tt.before, tt.after, got, tt.want)
+			}
+			if text := applyAll(tt.before, []Edit{got}); text != tt.after {
+				t.Errorf("applying the edit made %q, want %q", text, tt.after)
+			}
+		})
+	}
+
+	if _, ok := diff("same", "same"); ok {
+		t.Error("diff found a change between equal texts")
+	}
+}
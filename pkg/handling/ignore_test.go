@@ -0,0 +1,93 @@
+package handling
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Load an Ignore from a .gitignore holding rules, in a folder of its own.
+func loadIgnore(t *testing.T, rules string) (*Ignore, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(rules), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ig := &Ignore{}
+	ig.Load(dir)
+	return ig, dir
+}
+
+func TestIgnoreMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"name anywhere", "*.log", "a/b/debug.log", false, true},
+		{"name not matching", "*.log", "a/b/debug.txt", false, false},
+		{"comments and blanks", "# *.txt\n\n", "notes.txt", false, false},
+		{"negation", "*.log\n!keep.log", "keep.log", false, false},
+		{"negation only of its name", "*.log\n!keep.log", "other.log", false, true},
+		{"later rule wins", "!keep.log\n*.log", "keep.log", false, true},
+		{"directory pattern matches a directory", "build/", "src/build", true, true},
+		{"directory pattern skips a file", "build/", "src/build", false, false},
+		{"anchored at the root", "/todo", "todo", false, true},
+		{"anchored not deeper", "/todo", "sub/todo", false, false},
+		{"slash in the middle anchors", "doc/*.html", "doc/index.html", false, true},
+		{"slash in the middle not deeper", "doc/*.html", "sub/doc/index.html", false, false},
+		{"double star spans folders", "a/**/z", "a/b/c/z", false, true},
+		{"double star spans none", "a/**/z", "a/z", false, true},
+		{"leading double star", "**/cache", "x/y/cache", true, true},
+		{"escaped bang", `\!important`, "!important", false, true},
+		{"trailing spaces", "*.tmp   ", "a.tmp", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ig, dir := loadIgnore(t, tt.rules)
+			if got := ig.Match(filepath.Join(dir, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+				t.Errorf("Match(%q) with rules %q = %v, want %v", tt.path, tt.rules, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnoreNestedGitignore(t *testing.T) {
+	ig, dir := loadIgnore(t, "*.log\n")
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, ".gitignore"), []byte("!keep.log\n/local\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ig.Load(sub)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"sub/keep.log", false},
+		{"keep.log", true},
+		{"sub/other.log", true},
+		{"sub/local", true},
+		{"local", false},
+	}
+	for _, tt := range tests {
+		if got := ig.Match(filepath.Join(dir, filepath.FromSlash(tt.path)), false); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreOutsideBase(t *testing.T) {
+	ig, dir := loadIgnore(t, "*")
+	if ig.Match(filepath.Dir(dir), true) {
+		t.Error("a rule matched the folder above its .gitignore")
+	}
+	if ig.Match(dir, true) {
+		t.Error("a rule matched the folder holding its .gitignore")
+	}
+}
@@ -0,0 +1,146 @@
+package handling
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// Commands understood by a running Leda.
+const (
+	// CommandOpen opens Path at Line and Column.
+	CommandOpen = "open"
+	// CommandNew opens an untitled buffer holding Content.
+	CommandNew = "new"
+	// CommandFocus raises the window.
+	CommandFocus = "focus"
+	// CommandWait replies once every document opened with Wait on the connection is closed.
+	CommandWait = "wait"
+)
+
+// ErrRunning is returned by Listen when another Leda already owns the socket.
+var ErrRunning = errors.New("leda is already running")
+
+// Request is one command sent to a running Leda, one JSON object per line.
+type Request struct {
+	Command  string `json:"command"`
+	Path     string `json:"path,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Content  string `json:"content,omitempty"`
+	ReadOnly bool   `json:"readonly,omitempty"`
+	Wait     bool   `json:"wait,omitempty"`
+}
+
+// Reply answers a Request, reporting any error.
+type Reply struct {
+	Error string `json:"error,omitempty"`
+}
+
+// Listen claims the socket at path, replacing it when it was left behind by a
+// Leda that has gone. It returns ErrRunning when another Leda answers there.
+func Listen(path string) (net.Listener, error) {
+	listener, err := net.Listen("unix", path)
+	if err == nil {
+		return listener, nil
+	}
+
+	if conn, dialErr := net.DialTimeout("unix", path, time.Second); dialErr == nil {
+		conn.Close()
+		return nil, ErrRunning
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
+// Client sends requests to a running Leda.
+type Client struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	decoder *json.Decoder
+}
+
+// Dial connects to the Leda listening at path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, encoder: json.NewEncoder(conn), decoder: json.NewDecoder(bufio.NewReader(conn))}, nil
+}
+
+// Send sends a request and waits for its reply. CommandWait blocks until the
+// files it waits for are closed.
+func (c *Client) Send(request Request) error {
+	if err := c.encoder.Encode(request); err != nil {
+		return err
+	}
+	var reply Reply
+	if err := c.decoder.Decode(&reply); err != nil {
+		// Leda closes every file when it exits, which ends a wait as well.
+		if request.Command == CommandWait && errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	if reply.Error != "" {
+		return errors.New(reply.Error)
+	}
+	return nil
+}
+
+// Close ends the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Serve answers the requests sent over conn with handle until the connection closes.
+func Serve(conn net.Conn, handle func(Request) error) {
+	defer conn.Close()
+
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(bufio.NewReader(conn))
+	for {
+		var request Request
+		if err := decoder.Decode(&request); err != nil {
+			return
+		}
+
+		var reply Reply
+		if err := handle(request); err != nil {
+			reply.Error = err.Error()
+		}
+		if err := encoder.Encode(reply); err != nil {
+			return
+		}
+	}
+}
+
+// Requests turns a command line into the requests that hand it to a running Leda.
+func Requests(args *Args, stdin string) []Request {
+	var requests []Request
+	for _, location := range args.Paths {
+		requests = append(requests, Request{
+			Command:  CommandOpen,
+			Path:     location.Path,
+			Line:     location.Line,
+			Column:   location.Column,
+			ReadOnly: args.ReadOnly,
+			Wait:     args.Wait,
+		})
+	}
+	if args.Stdin {
+		requests = append(requests, Request{Command: CommandNew, Content: stdin, Wait: args.Wait})
+	}
+	requests = append(requests, Request{Command: CommandFocus})
+	if args.Wait && (len(args.Paths) > 0 || args.Stdin) {
+		requests = append(requests, Request{Command: CommandWait})
+	}
+	return requests
+}
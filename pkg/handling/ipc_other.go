@@ -0,0 +1,15 @@
+//go:build !unix
+
+package handling
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SocketPath reports that Leda cannot share its window here. Without Unix
+// permissions there is no way to keep the socket from other users, so each
+// Leda runs on its own.
+func SocketPath() (string, error) {
+	return "", fmt.Errorf("sharing one window: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package handling
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// SocketPath returns where the running Leda listens for other instances.
+// Outside the user's runtime folder, the socket is kept in a folder of its own
+// that only the user may enter, so nobody else can listen in their place.
+func SocketPath() (string, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), fmt.Sprintf("leda-%d", os.Getuid()))
+		if err := privateDir(dir); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(dir, fmt.Sprintf("leda-%d.sock", os.Getuid())), nil
+}
+
+// Create dir for the user alone, or check that the one already there is.
+func privateDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() || !private(info) {
+		return fmt.Errorf("%s is not a folder private to this user", dir)
+	}
+	return nil
+}
+
+// Report whether info is owned by the user and closed to everyone else.
+func private(info fs.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(stat.Uid) == os.Getuid() && info.Mode().Perm() == 0o700
+}
@@ -0,0 +1,125 @@
+package handling
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+)
+
+// Each running Leda journals to files of its own, named after its process ID,
+// so that one instance never recovers or removes another's live buffers.
+const (
+	journalFile = "session-%d.json"
+	runningFile = "running-%d"
+)
+
+// JournalEntry is the snapshot of one open buffer.
+type JournalEntry struct {
+	Title        string `json:"title"`
+	URI          string `json:"uri,omitempty"`
+	Content      string `json:"content"`
+	CursorRow    int    `json:"cursor_row"`
+	CursorColumn int    `json:"cursor_column"`
+	Dirty        bool   `json:"dirty"`
+}
+
+// writes a snapshot of every open buffer of this session into dir.
+func WriteJournal(dir fyne.URI, entries []JournalEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	_, err = SaveFileInDir(dir, fmt.Sprintf(journalFile, os.Getpid()), string(data))
+	return err
+}
+
+// reads the buffers the session with process ID pid last wrote to the journal in dir.
+func ReadJournal(dir fyne.URI, pid int) ([]JournalEntry, error) {
+	uri, err := storage.Child(dir, fmt.Sprintf(journalFile, pid))
+	if err != nil {
+		return nil, err
+	}
+	if exists, _ := storage.Exists(uri); !exists {
+		return nil, nil
+	}
+
+	reader, err := storage.Reader(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []JournalEntry
+	err = json.Unmarshal(data, &entries)
+	return entries, err
+}
+
+// records that this session is running, so a later start can spot an unclean shutdown.
+func MarkRunning(dir fyne.URI) error {
+	_, err := SaveFileInDir(dir, fmt.Sprintf(runningFile, os.Getpid()), "")
+	return err
+}
+
+// finds the process IDs of sessions marked running in dir that no longer are,
+// as they ended without ClearJournal being called.
+func StaleSessions(dir fyne.URI) []int {
+	listable, err := storage.ListerForURI(dir)
+	if err != nil {
+		return nil
+	}
+	uris, err := listable.List()
+	if err != nil {
+		return nil
+	}
+	var sessions []int
+	for _, uri := range uris {
+		var pid int
+		if _, err := fmt.Sscanf(uri.Name(), runningFile, &pid); err != nil || pid == os.Getpid() {
+			continue
+		}
+		if !processRunning(pid) {
+			sessions = append(sessions, pid)
+		}
+	}
+	return sessions
+}
+
+// removes the journal and running marker of the session with process ID pid.
+func ClearJournal(dir fyne.URI, pid int) {
+	for _, name := range []string{fmt.Sprintf(journalFile, pid), fmt.Sprintf(runningFile, pid)} {
+		uri, err := storage.Child(dir, name)
+		if err != nil {
+			continue
+		}
+		if exists, _ := storage.Exists(uri); exists {
+			if err := storage.Delete(uri); err != nil {
+				fyne.LogError("Failed to remove "+name, err)
+			}
+		}
+	}
+}
+
+// reports whether a process is still running. Windows cannot find a process
+// that has ended; elsewhere one is found regardless and signalled to check.
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		process.Release()
+		return true
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
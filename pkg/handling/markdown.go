@@ -0,0 +1,91 @@
+package handling
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// MarkdownBlock is a run of Markdown source rendered on its own, starting at Row.
+type MarkdownBlock struct {
+	Row  int
+	Text string
+}
+
+// SplitMarkdown cuts Markdown source at the start of its top-level blocks, such
+// as headings, paragraphs and lists, so each can be rendered apart and found
+// again from a source row. Link definitions are repeated in every block.
+func SplitMarkdown(source string) []MarkdownBlock {
+	if source == "" {
+		return nil
+	}
+	src := []byte(source)
+	context := parser.NewContext()
+	doc := goldmark.DefaultParser().Parse(text.NewReader(src), parser.WithContext(context))
+
+	var definitions strings.Builder
+	for _, ref := range context.References() {
+		// Titles come as written, so drop their escapes before escaping them again.
+		title := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(string(util.UnescapePunctuations(ref.Title())))
+		fmt.Fprintf(&definitions, "\n[%s]: <%s> \"%s\"\n", ref.Label(), ref.Destination(), title)
+	}
+
+	// Blocks with no known start, like rules, stay with the block before.
+	starts := []int{0}
+	for node := doc.FirstChild(); node != nil; node = node.NextSibling() {
+		if start, ok := blockStart(src, node); ok && node != doc.FirstChild() && start > starts[len(starts)-1] {
+			starts = append(starts, start)
+		}
+	}
+
+	blocks := make([]MarkdownBlock, len(starts))
+	row := 0
+	for i, start := range starts {
+		end := len(src)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		blocks[i] = MarkdownBlock{Row: row, Text: source[start:end] + definitions.String()}
+		row += strings.Count(source[start:end], "\n")
+	}
+	return blocks
+}
+
+// The offset of the start of the line a top-level block begins on.
+func blockStart(src []byte, node ast.Node) (int, bool) {
+	pos, ok := firstSegment(node)
+	if code, isCode := node.(*ast.FencedCodeBlock); isCode {
+		// The opening fence is on the line before the code, with the info string.
+		if code.Info != nil {
+			pos, ok = code.Info.Segment.Start, true
+		} else if ok {
+			pos = node.Lines().At(0).Start - 1
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	return bytes.LastIndexByte(src[:max(0, pos)], '\n') + 1, true
+}
+
+// The offset of the first text of a node or the nodes in it.
+func firstSegment(node ast.Node) (int, bool) {
+	if node.Type() == ast.TypeBlock && node.Lines().Len() > 0 {
+		return node.Lines().At(0).Start, true
+	}
+	if t, ok := node.(*ast.Text); ok {
+		return t.Segment.Start, true
+	}
+	for child := node.FirstChild(); child != nil; child = child.NextSibling() {
+		if pos, ok := firstSegment(child); ok {
+			return pos, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package handling
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yuin/goldmark"
+)
+
+func TestSplitMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []MarkdownBlock
+	}{
+		{"empty", "", nil},
+		{"one paragraph", "one\ntwo\n", []MarkdownBlock{{0, "one\ntwo\n"}}},
+		{
+			"heading and paragraphs",
+			"# Title\n\nfirst\nline\n\nsecond\n",
+			[]MarkdownBlock{{0, "# Title\n\n"}, {2, "first\nline\n\n"}, {5, "second\n"}},
+		},
+		{
+			"list",
+			"intro\n\n- a\n- b\n\nafter",
+			[]MarkdownBlock{{0, "intro\n\n"}, {2, "- a\n- b\n\n"}, {5, "after"}},
+		},
+		{
+			"fence with an info string",
+			"text\n\n```go\nfunc main() {}\n```\n\nafter\n",
+			[]MarkdownBlock{{0, "text\n\n"}, {2, "```go\nfunc main() {}\n```\n\n"}, {6, "after\n"}},
+		},
+		{
+			"fence without an info string",
+			"text\n\n```\ncode\n```\n\nafter\n",
+			[]MarkdownBlock{{0, "text\n\n"}, {2, "```\ncode\n```\n\n"}, {6, "after\n"}},
+		},
+		{
+			"fence opening the source",
+			"```\ncode\n```\ntext\n",
+			[]MarkdownBlock{{0, "```\ncode\n```\n"}, {3, "text\n"}},
+		},
+		{
+			"thematic break joins the block before",
+			"above\n\n---\n\nbelow\n",
+			[]MarkdownBlock{{0, "above\n\n---\n\n"}, {4, "below\n"}},
+		},
+		{
+			"thematic break opening the source",
+			"***\n\nbelow\n",
+			[]MarkdownBlock{{0, "***\n\n"}, {2, "below\n"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitMarkdown(tt.source); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitMarkdown(%q) =\n%q\nwant\n%q", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitMarkdownRepeatsReferences(t *testing.T) {
+	source := "See [docs][].\n\n# Heading\n\nAnd [home] too.\n\n[docs]: https://example.com/docs \"The \\\"docs\\\"\"\n[home]: /home\n"
+	blocks := SplitMarkdown(source)
+
+	var rows []int
+	for _, block := range blocks {
+		rows = append(rows, block.Row)
+	}
+	if want := []int{0, 2, 4}; !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %v, want %v; blocks %q", rows, want, blocks)
+	}
+
+	// Each block is rendered alone, so it needs every definition to resolve its links.
+	for i, block := range blocks {
+		for _, definition := range []string{
+			`[docs]: <https://example.com/docs> "The \"docs\""`,
+			`[home]: </home> ""`,
+		} {
+			if !strings.Contains(block.Text, definition) {
+				t.Errorf("block %d %q lacks %s", i, block.Text, definition)
+			}
+		}
+	}
+
+	var html bytes.Buffer
+	if err := goldmark.Convert([]byte(blocks[0].Text), &html); err != nil {
+		t.Fatal(err)
+	}
+	if want := `<a href="https://example.com/docs" title="The &quot;docs&quot;">docs</a>`; !strings.Contains(html.String(), want) {
+		t.Errorf("first block renders %q, want the link %s", html.String(), want)
+	}
+}
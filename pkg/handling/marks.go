@@ -0,0 +1,110 @@
+package handling
+
+import (
+	"image/color"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// Mark colors text from byte offset Start to End behind it. Line marks color
+// every row they touch across the whole view instead, or draw a rule above
+// the row of Start when it equals End.
+type Mark struct {
+	Start, End int
+	Line       bool
+	Color      color.Color
+}
+
+// SetMarks replaces the marks colored behind the text.
+func (e *Editor) SetMarks(marks []Mark) {
+	e.marks = slices.Clone(marks)
+	e.content.Refresh()
+}
+
+// SetGutterMarks replaces the line marks drawn in a strip down the gutter.
+func (e *Editor) SetGutterMarks(marks []Mark) {
+	e.gutterMarks = slices.Clone(marks)
+	e.gutterChanged()
+	e.gutter.Refresh()
+}
+
+// ScrollPosition returns the row at the top of the view, with the fraction of
+// it scrolled past, and how far the view is scrolled sideways.
+func (e *Editor) ScrollPosition() (float32, float32) {
+	lineHeight, _ := e.content.metrics()
+	return e.scroll.Offset.Y / lineHeight, e.scroll.Offset.X
+}
+
+// VisibleRows returns how many rows fit in the view.
+func (e *Editor) VisibleRows() float32 {
+	lineHeight, _ := e.content.metrics()
+	return e.scroll.Size().Height / lineHeight
+}
+
+// ScrollTo scrolls the view to put a row, or part way through one, at the top,
+// and scrolls it sideways by x.
+func (e *Editor) ScrollTo(row, x float32) {
+	lineHeight, _ := e.content.metrics()
+	size := e.scroll.Size()
+	e.content.Resize(e.content.MinSize().Max(size))
+	content := e.content.Size()
+	offset := fyne.NewPos(
+		max(0, min(x, content.Width-size.Width)),
+		max(0, min(row*lineHeight, content.Height-size.Height)),
+	)
+	if offset == e.scroll.Offset {
+		return
+	}
+	e.scroll.Offset = offset
+	e.scroll.Refresh()
+	e.content.Refresh()
+	e.gutter.Refresh()
+}
+
+// Draw the marks on the rows in view.
+func (r *contentRenderer) buildMarks(first, last int, width float32) {
+	e := r.content.editor
+	lineHeight, charWidth := r.content.metrics()
+	pad := r.content.padding()
+	for _, mark := range e.marks {
+		startRow, startCol := e.RowCol(mark.Start)
+		endRow, endCol := e.RowCol(mark.End)
+		if endRow < first || startRow > last {
+			continue
+		}
+		if mark.Line && mark.Start == mark.End {
+			r.addMark(mark.Color, fyne.NewPos(0, float32(startRow)*lineHeight-1), fyne.NewSize(width, 2))
+			continue
+		}
+		for row := max(first, startRow); row <= min(last, endRow); row++ {
+			if mark.Line {
+				r.addMark(mark.Color, fyne.NewPos(0, float32(row)*lineHeight), fyne.NewSize(width, lineHeight))
+				continue
+			}
+			line := e.Buffer.Line(row)
+			from, to := 0, visualColumn(line, len(line))
+			if row == startRow {
+				from = visualColumn(line, startCol)
+			}
+			if row == endRow {
+				to = visualColumn(line, endCol)
+			}
+			r.addMark(mark.Color, fyne.NewPos(pad+float32(from)*charWidth, float32(row)*lineHeight), fyne.NewSize(float32(to-from)*charWidth, lineHeight))
+		}
+	}
+}
+
+// Add a rectangle from the pool of marks to the objects drawn.
+func (r *contentRenderer) addMark(fill color.Color, pos fyne.Position, size fyne.Size) {
+	if r.markCount == len(r.marks) {
+		r.marks = append(r.marks, canvas.NewRectangle(color.Transparent))
+	}
+	rect := r.marks[r.markCount]
+	r.markCount++
+	rect.FillColor = fill
+	rect.Move(pos)
+	rect.Resize(size)
+	r.objects = append(r.objects, rect)
+}
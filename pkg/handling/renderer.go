@@ -1 +1,422 @@
 package handling
+
+import (
+	"image/color"
+	"math"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/syntax"
+)
+
+// cursorWidth is how wide the text cursor is drawn.
+const cursorWidth = 2
+
+// CreateRenderer draws the editor's background behind its scrolling content.
+func (e *Editor) CreateRenderer() fyne.WidgetRenderer {
+	r := &editorRenderer{editor: e, background: canvas.NewRectangle(color.Transparent)}
+	r.objects = []fyne.CanvasObject{r.background, e.gutterClip, e.scroll}
+	r.Refresh()
+	return r
+}
+
+// editorRenderer lays out an Editor.
+type editorRenderer struct {
+	editor     *Editor
+	background *canvas.Rectangle
+	objects    []fyne.CanvasObject
+}
+
+func (r *editorRenderer) Layout(size fyne.Size) {
+	e := r.editor
+	r.background.Resize(size)
+	e.gutterWidth = e.gutter.width()
+	e.gutterClip.Resize(fyne.NewSize(e.gutterWidth, size.Height))
+	e.scroll.Move(fyne.NewPos(e.gutterWidth, 0))
+	e.scroll.Resize(fyne.NewSize(size.Width-e.gutterWidth, size.Height))
+	if e.pendingScroll {
+		e.ScrollToCursor()
+	}
+}
+
+func (r *editorRenderer) MinSize() fyne.Size {
+	return r.editor.scroll.MinSize().AddWidthHeight(r.editor.gutter.width(), 0)
+}
+
+func (r *editorRenderer) Refresh() {
+	th := r.editor.Theme()
+	r.background.FillColor = th.Color(theme.ColorNameInputBackground, fyne.CurrentApp().Settings().ThemeVariant())
+	r.background.Refresh()
+	// The font size may have changed, so everything needs resizing.
+	r.Layout(r.editor.Size())
+	r.editor.scroll.Refresh()
+	r.editor.content.Refresh()
+	r.editor.gutterClip.Refresh()
+	r.editor.gutter.Refresh()
+}
+
+func (r *editorRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *editorRenderer) Destroy() {}
+
+// editorContent is the full-size text of an Editor inside its scroller,
+// drawing just the lines in view and handling the mouse.
+type editorContent struct {
+	widget.BaseWidget
+	editor *Editor
+
+	// width caches the widest line in columns, or -1 after the text was replaced.
+	// Edits only widen it, so it is not rescanned on every key.
+	width int
+	// dragAnchor is where the selection started when dragging.
+	dragAnchor int
+	// adding is set while dragging out a cursor added with Ctrl held.
+	adding bool
+	// block is set while dragging out a block selection with Alt held,
+	// which started at blockRow and the visual column blockColumn.
+	block       bool
+	blockRow    int
+	blockColumn int
+}
+
+// CreateRenderer draws the lines in view.
+func (c *editorContent) CreateRenderer() fyne.WidgetRenderer {
+	r := &contentRenderer{
+		content: c,
+		current: canvas.NewRectangle(color.Transparent),
+	}
+	r.build()
+	return r
+}
+
+// MinSize covers every line, so the scroller can reach all of them.
+func (c *editorContent) MinSize() fyne.Size {
+	lineHeight, charWidth := c.metrics()
+	if c.width < 0 {
+		c.width = 0
+		if c.editor.Buffer.Paged() {
+			// Reading the whole file to measure it would defeat paging, so
+			// the width grows as lines come into view instead.
+			return c.size(lineHeight, charWidth)
+		}
+		text := c.editor.Text()
+		for text != "" {
+			line, rest, _ := strings.Cut(text, "\n")
+			c.width = max(c.width, visualColumn(line, len(line)))
+			text = rest
+		}
+	}
+	return c.size(lineHeight, charWidth)
+}
+
+// The size covering every line at the measured width.
+func (c *editorContent) size(lineHeight, charWidth float32) fyne.Size {
+	return fyne.NewSize(2*c.padding()+float32(c.width)*charWidth+cursorWidth, float32(c.editor.Buffer.Lines())*lineHeight)
+}
+
+// Measure all the lines again and redraw.
+func (c *editorContent) textChanged() {
+	c.width = -1
+	c.editor.scroll.Refresh()
+	c.Refresh()
+	c.editor.gutterChanged()
+}
+
+// Widen the content for edited rows.
+func (c *editorContent) widen(first, last int) {
+	if c.width < 0 {
+		return
+	}
+	for row := first; row <= last; row++ {
+		line := c.editor.Buffer.Line(row)
+		c.width = max(c.width, visualColumn(line, len(line)))
+	}
+}
+
+// Redraw after edits, once widened for them.
+func (c *editorContent) linesChanged() {
+	c.editor.scroll.Refresh()
+	c.Refresh()
+	c.editor.gutterChanged()
+}
+
+// Make room for the line numbers when they gain or lose a digit.
+func (e *Editor) gutterChanged() {
+	if e.gutter.width() != e.gutterWidth {
+		e.Refresh()
+	}
+}
+
+// The height of a line and width of a character in the monospace font.
+func (c *editorContent) metrics() (float32, float32) {
+	size := c.editor.Theme().Size(theme.SizeNameText)
+	m := fyne.MeasureText("M", size, fyne.TextStyle{Monospace: true})
+	return m.Height, m.Width
+}
+
+// The space left of the first column.
+func (c *editorContent) padding() float32 {
+	return c.editor.Theme().Size(theme.SizeNameInnerPadding)
+}
+
+// The text offset nearest a point on the content.
+func (c *editorContent) offsetAt(pos fyne.Position) int {
+	row, visual := c.rowVisualAt(pos)
+	return c.editor.Offset(row, runeColumn(c.editor.Buffer.Line(row), visual))
+}
+
+// The row and visual column nearest a point on the content, which may be past the end of the line.
+func (c *editorContent) rowVisualAt(pos fyne.Position) (int, int) {
+	lineHeight, charWidth := c.metrics()
+	row := max(0, min(int(pos.Y/lineHeight), c.editor.Buffer.Lines()-1))
+	visual := int(math.Round(float64((pos.X - c.padding()) / charWidth)))
+	return row, max(0, visual)
+}
+
+// MouseDown places the cursor, or extends the selection with shift held.
+// Ctrl adds a cursor and Alt starts a block selection.
+func (c *editorContent) MouseDown(ev *desktop.MouseEvent) {
+	e := c.editor
+	if canvas := fyne.CurrentApp().Driver().CanvasForObject(e); canvas != nil {
+		canvas.Focus(e)
+	}
+	if ev.Button != desktop.MouseButtonPrimary {
+		return
+	}
+
+	pos := c.offsetAt(ev.Position)
+	c.adding, c.block = false, false
+	e.goalColumn = -1
+	switch {
+	case ev.Modifier&fyne.KeyModifierAlt != 0:
+		c.block = true
+		c.blockRow, c.blockColumn = c.rowVisualAt(ev.Position)
+		e.selectBlock(c.blockRow, c.blockColumn, c.blockRow, c.blockColumn)
+		return
+	case ev.Modifier&fyne.KeyModifierShortcutDefault != 0:
+		carets := e.Carets()
+		c.dragAnchor = pos
+		e.AddCaret(pos)
+		c.adding = e.Carets() > carets
+		return
+	}
+
+	if ev.Modifier&fyne.KeyModifierShift == 0 {
+		c.dragAnchor = pos
+	} else if start, end, ok := e.selection(); ok && e.CursorOffset() == start {
+		c.dragAnchor = end
+	} else if ok {
+		c.dragAnchor = start
+	} else {
+		c.dragAnchor = e.CursorOffset()
+	}
+	e.Select(c.dragAnchor, pos)
+}
+
+// MouseUp is needed to receive MouseDown.
+func (c *editorContent) MouseUp(*desktop.MouseEvent) {}
+
+// Dragged selects from where the drag started.
+func (c *editorContent) Dragged(ev *fyne.DragEvent) {
+	switch {
+	case c.block:
+		row, visual := c.rowVisualAt(ev.Position)
+		c.editor.selectBlock(c.blockRow, c.blockColumn, row, visual)
+	case c.adding:
+		c.editor.selectMain(c.dragAnchor, c.offsetAt(ev.Position))
+	default:
+		c.editor.Select(c.dragAnchor, c.offsetAt(ev.Position))
+	}
+}
+
+// DragEnd is needed to receive Dragged.
+func (c *editorContent) DragEnd() {}
+
+// DoubleTapped selects the word under the pointer.
+func (c *editorContent) DoubleTapped(ev *fyne.PointEvent) {
+	c.editor.SelectWord(c.offsetAt(ev.Position))
+}
+
+// TappedSecondary shows the edit menu.
+func (c *editorContent) TappedSecondary(ev *fyne.PointEvent) {
+	c.editor.showMenu(ev.AbsolutePosition)
+}
+
+// Cursor shows the text cursor over the editor.
+func (c *editorContent) Cursor() desktop.Cursor {
+	return desktop.TextCursor
+}
+
+// contentRenderer keeps pools of the objects drawing the lines in view.
+type contentRenderer struct {
+	content    *editorContent
+	current    *canvas.Rectangle
+	selections []*canvas.Rectangle
+	marks      []*canvas.Rectangle
+	markCount  int
+	texts      []*canvas.Text
+	cursors    []*canvas.Rectangle
+	objects    []fyne.CanvasObject
+}
+
+func (r *contentRenderer) Layout(fyne.Size) {
+	r.build()
+}
+
+func (r *contentRenderer) MinSize() fyne.Size {
+	return r.content.MinSize()
+}
+
+func (r *contentRenderer) Refresh() {
+	r.build()
+	canvas.Refresh(r.content)
+}
+
+func (r *contentRenderer) Objects() []fyne.CanvasObject {
+	return r.objects
+}
+
+func (r *contentRenderer) Destroy() {}
+
+// Position the selections, text and cursors of the lines in view.
+func (r *contentRenderer) build() {
+	e := r.content.editor
+	th := e.Theme()
+	variant := fyne.CurrentApp().Settings().ThemeVariant()
+	textSize := th.Size(theme.SizeNameText)
+	lineHeight, charWidth := r.content.metrics()
+	pad := r.content.padding()
+
+	offset, view := e.scroll.Offset, e.scroll.Size()
+	first := max(0, int(offset.Y/lineHeight))
+	last := min(e.Buffer.Lines()-1, int((offset.Y+view.Height)/lineHeight))
+	// Columns in view, so very long lines only draw what shows.
+	firstCol := max(0, int((offset.X-pad)/charWidth)-1)
+	lastCol := int((offset.X+view.Width)/charWidth) + 1
+
+	r.objects = r.objects[:0]
+	selections, texts := 0, 0
+
+	carets, primary := e.allCarets()
+	if start, end := carets[primary].ordered(); start == end && e.CursorRow >= first && e.CursorRow <= last {
+		r.current.FillColor = th.Color(theme.ColorNameHover, variant)
+		r.current.Move(fyne.NewPos(0, float32(e.CursorRow)*lineHeight))
+		r.current.Resize(fyne.NewSize(max(r.content.Size().Width, offset.X+view.Width), lineHeight))
+		r.objects = append(r.objects, r.current)
+	}
+	r.markCount = 0
+	r.buildMarks(first, last, max(r.content.Size().Width, offset.X+view.Width))
+
+	for _, c := range carets {
+		start, end := c.ordered()
+		if start == end {
+			continue
+		}
+		startRow, startCol := e.RowCol(start)
+		endRow, endCol := e.RowCol(end)
+		for row := max(first, startRow); row <= min(last, endRow); row++ {
+			line := e.Buffer.Line(row)
+			from, to := 0, visualColumn(line, len(line))+1
+			if row == startRow {
+				from = visualColumn(line, startCol)
+			}
+			if row == endRow {
+				to = visualColumn(line, endCol)
+			}
+			if selections == len(r.selections) {
+				r.selections = append(r.selections, canvas.NewRectangle(color.Transparent))
+			}
+			rect := r.selections[selections]
+			selections++
+			rect.FillColor = th.Color(theme.ColorNameSelection, variant)
+			rect.Move(fyne.NewPos(pad+float32(from)*charWidth, float32(row)*lineHeight))
+			rect.Resize(fyne.NewSize(float32(to-from)*charWidth, lineHeight))
+			r.objects = append(r.objects, rect)
+		}
+	}
+
+	wider := false
+	for row := first; row <= last; row++ {
+		line := e.Buffer.Line(row)
+		if width := visualColumn(line, len(line)); r.content.width >= 0 && width > r.content.width {
+			r.content.width = width
+			wider = true
+		}
+		visual := 0
+		for _, token := range e.tokens(row, line) {
+			text, next := expandTabs(line[token.Start:token.End], visual)
+			from, to := max(visual, firstCol), min(next, lastCol)
+			if from < to && strings.TrimSpace(text) != "" {
+				runes := []rune(text)
+				if texts == len(r.texts) {
+					r.texts = append(r.texts, canvas.NewText("", color.Transparent))
+				}
+				t := r.texts[texts]
+				texts++
+				t.Text = string(runes[from-visual : to-visual])
+				t.Color = th.Color(token.Kind.ColorName(), variant)
+				t.TextSize = textSize
+				t.TextStyle = fyne.TextStyle{Monospace: true}
+				t.Move(fyne.NewPos(pad+float32(from)*charWidth, float32(row)*lineHeight))
+				t.Resize(fyne.NewSize(float32(to-from)*charWidth, lineHeight))
+				r.objects = append(r.objects, t)
+			}
+			visual = next
+		}
+	}
+
+	cursors := 0
+	for _, c := range carets {
+		row, col := e.RowCol(c.head)
+		if !e.focused || row < first || row > last {
+			continue
+		}
+		if cursors == len(r.cursors) {
+			r.cursors = append(r.cursors, canvas.NewRectangle(color.Transparent))
+		}
+		cursor := r.cursors[cursors]
+		cursors++
+		cursor.FillColor = th.Color(theme.ColorNamePrimary, variant)
+		cursor.Move(fyne.NewPos(pad+float32(visualColumn(e.Buffer.Line(row), col))*charWidth, float32(row)*lineHeight))
+		cursor.Resize(fyne.NewSize(cursorWidth, lineHeight))
+		r.objects = append(r.objects, cursor)
+	}
+	if wider {
+		// Let the scroller reach the newly seen columns.
+		e.scroll.Refresh()
+	}
+}
+
+// The tokens coloring a line, or the plain line when the highlighter is behind.
+func (e *Editor) tokens(row int, line string) []syntax.Token {
+	h := e.Highlighter
+	if h != nil && h.Len() == e.Buffer.Lines() && h.Text(row) == line {
+		return h.Tokens(row)
+	}
+	return []syntax.Token{{Start: 0, End: len(line), Kind: syntax.Plain}}
+}
+
+// Expand the tabs in text starting at a visual column, returning the column it ends at.
+func expandTabs(text string, visual int) (string, int) {
+	if !strings.Contains(text, "\t") {
+		return text, visual + len([]rune(text))
+	}
+	var b strings.Builder
+	for _, r := range text {
+		if r == '\t' {
+			spaces := tabWidth - visual%tabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			visual += spaces
+		} else {
+			b.WriteRune(r)
+			visual++
+		}
+	}
+	return b.String(), visual
+}
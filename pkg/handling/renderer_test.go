@@ -0,0 +1,60 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/syntax"
+)
+
+func TestEditorTokensFollowEdits(t *testing.T) {
+	test.NewApp()
+	e := NewEditor()
+	e.Load("a := 1\nb := 2\nc := 3")
+	e.Highlighter = syntax.NewHighlighter(syntax.Lookup("Go"))
+	e.Highlighter.SetText(e.Text())
+
+	// Edits reach the highlighter, so opening a comment colors the rows below it.
+	e.replace(0, 0, "/* ")
+	e.replace(e.Buffer.Len(), e.Buffer.Len(), "\nd */")
+	for row := range e.Buffer.Lines() {
+		tokens := e.tokens(row, e.Buffer.Line(row))
+		if len(tokens) == 0 || tokens[0].Kind != syntax.Comment {
+			t.Errorf("row %d tokens = %v, want a comment", row, tokens)
+		}
+	}
+
+	// Undoing both goes back to the tokens the text started with.
+	e.Undo()
+	e.Undo()
+	fresh := syntax.NewHighlighter(syntax.Lookup("Go"))
+	fresh.SetText(e.Text())
+	for row := range e.Buffer.Lines() {
+		if got, want := e.tokens(row, e.Buffer.Line(row)), fresh.Tokens(row); !reflect.DeepEqual(got, want) {
+			t.Errorf("row %d tokens after undo = %v, want %v", row, got, want)
+		}
+	}
+}
+
+func TestEditorTokensFallBackWhenStale(t *testing.T) {
+	e := &Editor{Buffer: NewBuffer("x := 1\ny := 2")}
+	e.Highlighter = syntax.NewHighlighter(syntax.Lookup("Go"))
+	e.Highlighter.SetText("x := 1")
+
+	plain := func(line string) []syntax.Token {
+		return []syntax.Token{{Start: 0, End: len(line), Kind: syntax.Plain}}
+	}
+	// A highlighter with another number of lines is behind the buffer.
+	if got := e.tokens(0, "x := 1"); !reflect.DeepEqual(got, plain("x := 1")) {
+		t.Errorf("tokens with the line count behind = %v, want plain", got)
+	}
+	// As is one holding other text for the line.
+	e.Highlighter.SetText("x := 1\nz := 3")
+	if got := e.tokens(1, "y := 2"); !reflect.DeepEqual(got, plain("y := 2")) {
+		t.Errorf("tokens of a changed line = %v, want plain", got)
+	}
+	if got := e.tokens(0, "x := 1"); reflect.DeepEqual(got, plain("x := 1")) {
+		t.Error("tokens of an up to date line are plain")
+	}
+}
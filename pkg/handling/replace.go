@@ -0,0 +1,127 @@
+package handling
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReplaceMatches returns text with each match swapped for the replacement at the same index.
+// Matches must be in order and must not overlap.
+func ReplaceMatches(text string, matches []Match, replacements []string) string {
+	var b strings.Builder
+	last := 0
+	for i, match := range matches {
+		b.WriteString(text[last:match.Start])
+		b.WriteString(replacements[i])
+		last = match.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// rename moves files into place; tests swap it to make a rename fail.
+var rename = os.Rename
+
+// WriteFiles replaces the content of several files together. Every file is first
+// backed up and written to a temporary sibling, and only once all of them are
+// written are they renamed into place. Should a rename fail, the files already
+// renamed are put back from their backups, so a failure part way leaves the
+// originals untouched.
+func WriteFiles(contents map[string]string) error {
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	temps := make(map[string]string, len(paths))
+	backups := make(map[string]string, len(paths))
+	cleanup := func() {
+		for _, temp := range temps {
+			os.Remove(temp)
+		}
+		for _, backup := range backups {
+			os.Remove(backup)
+		}
+	}
+
+	for _, path := range paths {
+		original, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			cleanup()
+			return err
+		}
+		if err == nil {
+			backup, err := writeTemp(path, string(original))
+			if err != nil {
+				cleanup()
+				return err
+			}
+			backups[path] = backup
+		}
+
+		temp, err := writeTemp(path, contents[path])
+		if err != nil {
+			cleanup()
+			return err
+		}
+		temps[path] = temp
+	}
+
+	for i, path := range paths {
+		if err := rename(temps[path], path); err != nil {
+			err = errors.Join(err, restoreFiles(paths[:i], backups))
+			cleanup()
+			return err
+		}
+		delete(temps, path)
+	}
+	cleanup()
+	return nil
+}
+
+// Put back the files at paths from their backups, removing those that had
+// none, and report any that could not be.
+func restoreFiles(paths []string, backups map[string]string) error {
+	var errs []error
+	for _, path := range paths {
+		backup, ok := backups[path]
+		var err error
+		if ok {
+			err = rename(backup, path)
+			delete(backups, path)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not restore %s from %s: %w", path, backup, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Write content to a temporary file next to path, keeping path's permissions.
+func writeTemp(path, content string) (string, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(path); err == nil {
+		file.Chmod(info.Mode().Perm())
+	}
+
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
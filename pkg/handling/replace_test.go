@@ -0,0 +1,105 @@
+package handling
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Write files into dir, named by the keys of files.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Check that dir holds exactly the files given, with their content.
+func checkFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	var wantNames []string
+	for name := range want {
+		wantNames = append(wantNames, name)
+	}
+	slices.Sort(wantNames)
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("files = %v, want %v", names, wantNames)
+	}
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil && string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "old a", "b.txt": "old b"})
+	err := WriteFiles(map[string]string{
+		filepath.Join(dir, "a.txt"): "new a",
+		filepath.Join(dir, "b.txt"): "new b",
+		filepath.Join(dir, "c.txt"): "new c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, dir, map[string]string{"a.txt": "new a", "b.txt": "new b", "c.txt": "new c"})
+}
+
+func TestWriteFilesRenameFailureRestoresEarlierFiles(t *testing.T) {
+	dir := t.TempDir()
+	original := map[string]string{"a.txt": "old a", "b.txt": "old b", "d.txt": "old d"}
+	writeFiles(t, dir, original)
+
+	// Files are renamed in order of path, so a, b and the new c are in place
+	// when d fails.
+	failing := filepath.Join(dir, "d.txt")
+	rename = func(from, to string) error {
+		if to == failing {
+			return errors.New("rename failed")
+		}
+		return os.Rename(from, to)
+	}
+	defer func() { rename = os.Rename }()
+
+	err := WriteFiles(map[string]string{
+		filepath.Join(dir, "a.txt"): "new a",
+		filepath.Join(dir, "b.txt"): "new b",
+		filepath.Join(dir, "c.txt"): "new c",
+		failing:                     "new d",
+	})
+	if err == nil {
+		t.Fatal("WriteFiles succeeded despite a failed rename")
+	}
+	checkFiles(t, dir, original)
+}
+
+func TestWriteFilesFailureBeforeRenaming(t *testing.T) {
+	dir := t.TempDir()
+	original := map[string]string{"a.txt": "old a", "b.txt": "old b"}
+	writeFiles(t, dir, original)
+
+	// The last file's folder is missing, so its temporary file cannot be written.
+	err := WriteFiles(map[string]string{
+		filepath.Join(dir, "a.txt"):            "new a",
+		filepath.Join(dir, "b.txt"):            "new b",
+		filepath.Join(dir, "missing", "c.txt"): "new c",
+	})
+	if err == nil {
+		t.Fatal("WriteFiles succeeded writing into a missing folder")
+	}
+	checkFiles(t, dir, original)
+}
@@ -0,0 +1,84 @@
+package handling
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SearchOptions selects how a search term is matched.
+type SearchOptions struct {
+	CaseSensitive bool
+	WholeWord     bool
+	Regexp        bool
+}
+
+// Match is the byte range of one occurrence of a search term.
+type Match struct {
+	Start, End int
+
+	// groups holds the submatch offsets used to expand replacements.
+	groups []int
+}
+
+// Matcher finds occurrences of a search term in text.
+type Matcher struct {
+	re      *regexp.Regexp
+	literal bool
+}
+
+// NewMatcher compiles term according to opts. Terms that are not valid Go
+// regular expressions return an error in regexp mode.
+func NewMatcher(term string, opts SearchOptions) (*Matcher, error) {
+	pattern := term
+	if !opts.Regexp {
+		pattern = regexp.QuoteMeta(term)
+	}
+	if opts.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !opts.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{re: re, literal: !opts.Regexp}, nil
+}
+
+// FindAll returns every non-empty match in text, in order.
+func (m *Matcher) FindAll(text string) []Match {
+	matches := []Match{}
+	for _, loc := range m.re.FindAllStringSubmatchIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		matches = append(matches, Match{Start: loc[0], End: loc[1], groups: loc})
+	}
+	return matches
+}
+
+// Expand returns the replacement for a match found in text. In regexp mode
+// replace may refer to capture groups as $1 or ${name}.
+func (m *Matcher) Expand(text string, match Match, replace string) string {
+	if m.literal {
+		return replace
+	}
+	return string(m.re.ExpandString(nil, replace, text, match.groups))
+}
+
+// ReplaceAll replaces every match in text, returning the new text and how many matches were replaced.
+func (m *Matcher) ReplaceAll(text, replace string) (string, int) {
+	matches := m.FindAll(text)
+
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		b.WriteString(text[last:match.Start])
+		b.WriteString(m.Expand(text, match, replace))
+		last = match.End
+	}
+	b.WriteString(text[last:])
+	return b.String(), len(matches)
+}
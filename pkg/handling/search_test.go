@@ -0,0 +1,98 @@
+package handling
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatcherFindAll(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		opts SearchOptions
+		text string
+		want [][2]int
+	}{
+		{"literal", "foo", SearchOptions{CaseSensitive: true}, "foo bar foo", [][2]int{{0, 3}, {8, 11}}},
+		{"case sensitive", "Foo", SearchOptions{CaseSensitive: true}, "foo Foo FOO", [][2]int{{4, 7}}},
+		{"case insensitive", "foo", SearchOptions{}, "foo Foo FOO", [][2]int{{0, 3}, {4, 7}, {8, 11}}},
+		{"metacharacters are literal", "a.b", SearchOptions{CaseSensitive: true}, "a.b axb", [][2]int{{0, 3}}},
+		{"whole word", "cat", SearchOptions{CaseSensitive: true, WholeWord: true}, "cat concat cats cat_ cat.", [][2]int{{0, 3}, {21, 24}}},
+		{"whole word alternation", "a|b", SearchOptions{CaseSensitive: true, WholeWord: true, Regexp: true}, "a ab b", [][2]int{{0, 1}, {5, 6}}},
+		{"regexp", `\d+`, SearchOptions{Regexp: true}, "a1 b22 c333", [][2]int{{1, 2}, {4, 6}, {8, 11}}},
+		{"empty matches skipped", `x*`, SearchOptions{Regexp: true}, "axxb", [][2]int{{1, 3}}},
+		{"multi-byte", "é", SearchOptions{CaseSensitive: true}, "café é", [][2]int{{3, 5}, {6, 8}}},
+		{"no match", "zzz", SearchOptions{}, "abc", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMatcher(tt.term, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][2]int
+			for _, match := range m.FindAll(tt.text) {
+				got = append(got, [2]int{match.Start, match.End})
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FindAll(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMatcherInvalidRegexp(t *testing.T) {
+	if _, err := NewMatcher("a(", SearchOptions{Regexp: true}); err == nil {
+		t.Error("NewMatcher accepted an unbalanced regular expression")
+	}
+	if _, err := NewMatcher("a(", SearchOptions{}); err != nil {
+		t.Errorf("NewMatcher rejected a literal term: %v", err)
+	}
+}
+
+func TestMatcherReplaceAll(t *testing.T) {
+	tests := []struct {
+		name          string
+		term, replace string
+		opts          SearchOptions
+		text          string
+		want          string
+		count         int
+	}{
+		{"literal", "cat", "dog", SearchOptions{CaseSensitive: true}, "cat and cat", "dog and dog", 2},
+		{"literal keeps dollars", "cat", "$1", SearchOptions{CaseSensitive: true}, "a cat", "a $1", 1},
+		{"numbered groups", `(\w+)@(\w+)`, "$2 at $1", SearchOptions{Regexp: true}, "me@home", "home at me", 1},
+		{"named groups", `(?P<key>\w+)=(?P<value>\w+)`, "${value}=${key}", SearchOptions{Regexp: true}, "a=1, b=2", "1=a, 2=b", 2},
+		{"whole word", "in", "out", SearchOptions{WholeWord: true}, "in inside In", "out inside out", 2},
+		{"nothing found", "x", "y", SearchOptions{}, "abc", "abc", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMatcher(tt.term, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, count := m.ReplaceAll(tt.text, tt.replace)
+			if got != tt.want || count != tt.count {
+				t.Errorf("ReplaceAll(%q, %q) = %q, %d, want %q, %d", tt.text, tt.replace, got, count, tt.want, tt.count)
+			}
+		})
+	}
+}
+
+func TestMatcherExpand(t *testing.T) {
+	m, err := NewMatcher(`(\d+)-(\d+)`, SearchOptions{Regexp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "pages 10-20 and 3-4"
+	matches := m.FindAll(text)
+	if len(matches) != 2 {
+		t.Fatalf("found %d matches, want 2", len(matches))
+	}
+	for i, want := range []string{"20-10", "4-3"} {
+		if got := m.Expand(text, matches[i], "$2-$1"); got != want {
+			t.Errorf("Expand of match %d = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handling
+
+import "slices"
+
+// NewView creates another editor on the same buffer, undo history and
+// highlighting, with its own cursors and scrolling. Edits made in either
+// show in both.
+func (e *Editor) NewView() *Editor {
+	v := NewEditor()
+	v.Buffer = e.Buffer
+	v.History = e.History
+	v.Highlighter = e.Highlighter
+	v.ReadOnly = e.ReadOnly
+	v.OnChanged = e.OnChanged
+	v.OnCursorChanged = e.OnCursorChanged
+	v.lineNumbers = e.lineNumbers
+	v.CursorRow, v.CursorColumn = e.CursorRow, e.CursorColumn
+	v.content.width = e.content.width
+	*e.views = append(*e.views, v)
+	v.views = e.views
+	return v
+}
+
+// Views returns every editor showing the buffer, including this one.
+func (e *Editor) Views() []*Editor {
+	return slices.Clone(*e.views)
+}
+
+// CloseView stops the editor following edits made in the other views of its buffer.
+func (e *Editor) CloseView() {
+	views := slices.DeleteFunc(*e.views, func(v *Editor) bool { return v == e })
+	*e.views = views
+	e.views = &[]*Editor{e}
+}
+
+// The other views' cursors, saved as offsets before edits move the text under them.
+func (e *Editor) otherCarets() map[*Editor][]caret {
+	others := make(map[*Editor][]caret)
+	for _, v := range *e.views {
+		if v != e {
+			cs, primary := v.allCarets()
+			// Keep the main cursor last so it can be found again.
+			main := cs[primary]
+			others[v] = append(slices.Delete(cs, primary, primary+1), main)
+		}
+	}
+	return others
+}
+
+// Move the other views' cursors past edits made in this one and redraw them.
+func (e *Editor) editsMade(edits []Edit, others map[*Editor][]caret) {
+	for v, cs := range others {
+		for i := range cs {
+			cs[i] = caret{shiftOffset(cs[i].anchor, edits), shiftOffset(cs[i].head, edits)}
+		}
+		for _, edit := range edits {
+			first, _ := v.RowCol(edit.Pos)
+			last, _ := v.RowCol(edit.Pos + len(edit.Inserted))
+			v.content.widen(first, last)
+		}
+		v.setCarets(cs, len(cs)-1)
+		v.mergeCarets()
+		v.content.linesChanged()
+		v.gutter.Refresh()
+	}
+}
+
+// Where an offset ends up after edits made in order. Offsets in deleted text
+// move to where it was.
+func shiftOffset(pos int, edits []Edit) int {
+	for _, edit := range edits {
+		switch end := edit.Pos + len(edit.Deleted); {
+		case pos >= end && pos > edit.Pos:
+			pos += len(edit.Inserted) - len(edit.Deleted)
+		case pos > edit.Pos:
+			pos = edit.Pos
+		}
+	}
+	return pos
+}
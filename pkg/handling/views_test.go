@@ -0,0 +1,141 @@
+package handling
+
+import (
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestShiftOffset(t *testing.T) {
+	tests := []struct {
+		name  string
+		pos   int
+		edits []Edit
+		want  int
+	}{
+		{"before an insert", 2, []Edit{{Pos: 5, Inserted: "abc"}}, 2},
+		{"at an insert", 5, []Edit{{Pos: 5, Inserted: "abc"}}, 5},
+		{"after an insert", 7, []Edit{{Pos: 5, Inserted: "abc"}}, 10},
+		{"before a delete", 2, []Edit{{Pos: 5, Deleted: "abc"}}, 2},
+		{"at the start of a delete", 5, []Edit{{Pos: 5, Deleted: "abc"}}, 5},
+		{"inside a delete", 6, []Edit{{Pos: 5, Deleted: "abc"}}, 5},
+		{"at the end of a delete", 8, []Edit{{Pos: 5, Deleted: "abc"}}, 5},
+		{"after a delete", 10, []Edit{{Pos: 5, Deleted: "abc"}}, 7},
+		{"inside a replace", 7, []Edit{{Pos: 5, Deleted: "abc", Inserted: "x"}}, 5},
+		{"at the end of a replace", 8, []Edit{{Pos: 5, Deleted: "abc", Inserted: "xy"}}, 7},
+		{"after a replace", 10, []Edit{{Pos: 5, Deleted: "abc", Inserted: "xy"}}, 9},
+		{
+			// editCarets makes its edits from the end of the text backwards.
+			"between edits made backwards", 6,
+			[]Edit{{Pos: 10, Deleted: "ab", Inserted: "x"}, {Pos: 2, Inserted: "yyy"}},
+			9,
+		},
+		{
+			"after edits made backwards", 13,
+			[]Edit{{Pos: 10, Deleted: "ab", Inserted: "x"}, {Pos: 2, Inserted: "yyy"}},
+			15,
+		},
+		{
+			"inside a later delete", 11,
+			[]Edit{{Pos: 10, Deleted: "ab"}, {Pos: 2, Inserted: "yyy"}},
+			13,
+		},
+		{
+			// Undo makes edits forwards, each at offsets after the ones before.
+			"after edits made forwards", 20,
+			[]Edit{{Pos: 2, Inserted: "yyy"}, {Pos: 13, Deleted: "ab", Inserted: "x"}},
+			22,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shiftOffset(tt.pos, tt.edits); got != tt.want {
+				t.Errorf("shiftOffset(%d, %v) = %d, want %d", tt.pos, tt.edits, got, tt.want)
+			}
+		})
+	}
+}
+
+// The cursors of e in order through the text.
+func caretsOf(e *Editor) []caret {
+	cs, _ := e.allCarets()
+	return cs
+}
+
+func TestEditsMadeMovesOtherViewCarets(t *testing.T) {
+	test.NewApp()
+	e := NewEditor()
+	e.Load("one two three\nfour five")
+	v := e.NewView()
+
+	// The other view has a cursor before, inside and after the text e replaces,
+	// and one on the next line.
+	v.selectMain(1, 1)
+	v.AddCaret(5)
+	v.AddSelection(9, 12)
+	v.AddCaret(16)
+	e.selectMain(4, 8)
+	e.insert("2 ")
+
+	if got := e.Text(); got != "one 2 three\nfour five" {
+		t.Fatalf("text = %q", got)
+	}
+	want := []caret{{1, 1}, {4, 4}, {7, 10}, {14, 14}}
+	if got := caretsOf(v); !reflect.DeepEqual(got, want) {
+		t.Errorf("other view's cursors = %v, want %v", got, want)
+	}
+	// The main cursor stays the main cursor.
+	if got := v.primary(); got != (caret{14, 14}) {
+		t.Errorf("other view's main cursor = %v, want {14 14}", got)
+	}
+	if got := v.Buffer.Slice(7, 10); got != "hre" {
+		t.Errorf("other view's selection = %q, want %q", got, "hre")
+	}
+}
+
+func TestEditsMadeMergesOverlappingCarets(t *testing.T) {
+	test.NewApp()
+	e := NewEditor()
+	e.Load("abcdefgh")
+	v := e.NewView()
+
+	// Both cursors sit in the text e deletes, so they land together and merge.
+	v.selectMain(3, 3)
+	v.AddCaret(5)
+	v.AddCaret(7)
+	e.selectMain(2, 6)
+	e.insert("")
+
+	want := []caret{{2, 2}, {3, 3}}
+	if got := caretsOf(v); !reflect.DeepEqual(got, want) {
+		t.Errorf("other view's cursors = %v, want %v", got, want)
+	}
+	if got := v.Carets(); got != 2 {
+		t.Errorf("other view has %d cursors, want 2", got)
+	}
+}
+
+func TestEditsMadeByEveryCaret(t *testing.T) {
+	test.NewApp()
+	e := NewEditor()
+	e.Load("a\nb\nc")
+	v := e.NewView()
+	v.selectMain(2, 2)
+	v.AddCaret(5)
+
+	// Each line gets a prefix from its own cursor, shifting the later carets of
+	// the other view by every edit before them.
+	e.selectMain(0, 0)
+	e.AddCaret(2)
+	e.AddCaret(4)
+	e.insert("> ")
+
+	if got := e.Text(); got != "> a\n> b\n> c" {
+		t.Fatalf("text = %q", got)
+	}
+	want := []caret{{4, 4}, {11, 11}}
+	if got := caretsOf(v); !reflect.DeepEqual(got, want) {
+		t.Errorf("other view's cursors = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package syntax
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// rootState is the state every document starts in.
+const rootState = "root"
+
+// line caches the tokens of one line along with the states around it.
+type line struct {
+	text   string
+	start  string
+	end    string
+	tokens []Token
+	lexed  bool
+}
+
+// Highlighter tokenizes a document line by line. Lines are only lexed when
+// asked for, and an edit only re-lexes from the changed line until the state
+// carried between lines matches what it was before.
+type Highlighter struct {
+	grammar *Grammar
+	lines   []line
+	// valid counts the leading lines known to be up to date.
+	valid int
+}
+
+// NewHighlighter creates a highlighter for a grammar, which may be nil for plain text.
+func NewHighlighter(g *Grammar) *Highlighter {
+	return &Highlighter{grammar: g}
+}
+
+// Grammar returns the language being highlighted, nil for plain text.
+func (h *Highlighter) Grammar() *Grammar {
+	return h.grammar
+}
+
+// SetText updates the document, keeping the cached tokens of lines outside the change.
+func (h *Highlighter) SetText(text string) {
+	lines := strings.Split(text, "\n")
+
+	prefix := 0
+	for prefix < len(lines) && prefix < len(h.lines) && lines[prefix] == h.lines[prefix].text {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(lines)-prefix && suffix < len(h.lines)-prefix &&
+		lines[len(lines)-1-suffix] == h.lines[len(h.lines)-1-suffix].text {
+		suffix++
+	}
+
+	h.Replace(prefix, len(h.lines)-prefix-suffix, lines[prefix:len(lines)-suffix])
+}
+
+// Replace swaps the removed lines starting at row for lines, as an edit to the
+// document does. Lines after the change keep their tokens, which stay valid if
+// they start in the same state, so only the edited lines need lexing again.
+func (h *Highlighter) Replace(row, removed int, lines []string) {
+	changed := make([]line, len(lines))
+	for i := range changed {
+		changed[i].text = lines[i]
+	}
+	h.lines = slices.Replace(h.lines, row, row+removed, changed...)
+	h.valid = min(h.valid, row)
+}
+
+// Len returns the number of lines.
+func (h *Highlighter) Len() int {
+	return len(h.lines)
+}
+
+// Text returns the text of a line.
+func (h *Highlighter) Text(row int) string {
+	return h.lines[row].text
+}
+
+// Tokens returns the tokens covering a line, lexing any earlier lines that changed.
+func (h *Highlighter) Tokens(row int) []Token {
+	if row < 0 || row >= len(h.lines) {
+		return nil
+	}
+	for ; h.valid <= row; h.valid++ {
+		start := rootState
+		if h.valid > 0 {
+			start = h.lines[h.valid-1].end
+		}
+		l := &h.lines[h.valid]
+		if !l.lexed || l.start != start {
+			l.tokens, l.end = h.lex(l.text, start)
+			l.start, l.lexed = start, true
+		}
+	}
+	return h.lines[row].tokens
+}
+
+// Split a line into tokens, starting in state and returning the state it ends in.
+func (h *Highlighter) lex(text, state string) ([]Token, string) {
+	if h.grammar == nil {
+		return []Token{{Start: 0, End: len(text), Kind: Plain}}, state
+	}
+
+	stack := strings.Split(state, "/")
+	var tokens []Token
+	add := func(start, end int, kind Kind) {
+		if start == end {
+			return
+		}
+		if n := len(tokens); n > 0 && tokens[n-1].Kind == kind && tokens[n-1].End == start {
+			tokens[n-1].End = end
+			return
+		}
+		tokens = append(tokens, Token{Start: start, End: end, Kind: kind})
+	}
+
+	for pos := 0; pos < len(text); {
+		matched := false
+		for _, rule := range h.grammar.States[stack[len(stack)-1]] {
+			if rule.LineStart && pos != 0 {
+				continue
+			}
+			loc := rule.re.FindStringSubmatchIndex(text[pos:])
+			// An empty match has to change state, or lexing would never move on.
+			if loc == nil || (loc[1] == 0 && rule.Push == "" && !rule.Pop) {
+				continue
+			}
+
+			if len(rule.Groups) == 0 {
+				add(pos, pos+loc[1], rule.Kind)
+			} else {
+				last := 0
+				for i, kind := range rule.Groups {
+					start, end := loc[2+2*i], loc[3+2*i]
+					if start < 0 {
+						continue
+					}
+					add(pos+last, pos+start, Plain)
+					add(pos+start, pos+end, kind)
+					last = end
+				}
+				add(pos+last, pos+loc[1], Plain)
+			}
+
+			if rule.Pop && len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			if rule.Push != "" {
+				stack = append(stack, rule.Push)
+			}
+			pos += loc[1]
+			matched = true
+			break
+		}
+
+		if !matched {
+			_, size := utf8.DecodeRuneInString(text[pos:])
+			add(pos, pos+size, Plain)
+			pos += size
+		}
+	}
+	return tokens, strings.Join(stack, "/")
+}
@@ -0,0 +1,177 @@
+package syntax
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The kind of the token covering byte col of a line's tokens.
+func kindAt(tokens []Token, col int) Kind {
+	for _, token := range tokens {
+		if col >= token.Start && col < token.End {
+			return token.Kind
+		}
+	}
+	return -1
+}
+
+// A highlighter for text in the named language, with every line lexed.
+func highlight(t *testing.T, language, text string) *Highlighter {
+	t.Helper()
+	h := NewHighlighter(Lookup(language))
+	h.SetText(text)
+	for row := range h.Len() {
+		h.Tokens(row)
+	}
+	return h
+}
+
+// Check that h has the lines of text and the tokens a fresh highlighter gives them.
+func checkFresh(t *testing.T, h *Highlighter, text string) {
+	t.Helper()
+	fresh := NewHighlighter(h.Grammar())
+	fresh.SetText(text)
+	if h.Len() != fresh.Len() {
+		t.Fatalf("Len = %d, want %d", h.Len(), fresh.Len())
+	}
+	for row := range fresh.Len() {
+		if h.Text(row) != fresh.Text(row) {
+			t.Errorf("Text(%d) = %q, want %q", row, h.Text(row), fresh.Text(row))
+		}
+		if got, want := h.Tokens(row), fresh.Tokens(row); !reflect.DeepEqual(got, want) {
+			t.Errorf("Tokens(%d) of %q = %v, want %v", row, h.Text(row), got, want)
+		}
+	}
+}
+
+func TestHighlighterTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		line     string
+		col      int
+		want     Kind
+	}{
+		{"keyword", "Go", "func main() {}", 0, Keyword},
+		{"function call", "Go", "func main() {}", 5, Function},
+		{"type", "Go", "var n int", 6, Type},
+		{"number", "Go", "x := 0x1F", 6, Number},
+		{"operator", "Go", "x := 1", 2, Operator},
+		{"string", "Go", `s := "a // b"`, 8, String},
+		{"line comment", "Go", "x := 1 // note", 10, Comment},
+		{"identifier is plain", "Go", "value := 1", 2, Plain},
+		{"line start rule", "Markdown", "# Title", 2, Keyword},
+		{"line start rule mid line", "Markdown", "a # Title", 3, Plain},
+		{"capture groups", "YAML", "name: leda", 0, Property},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := highlight(t, tt.language, tt.line)
+			if got := kindAt(h.Tokens(0), tt.col); got != tt.want {
+				t.Errorf("kind at %d of %q = %v, want %v; tokens %v", tt.col, tt.line, got, tt.want, h.Tokens(0))
+			}
+		})
+	}
+}
+
+func TestHighlighterPlainText(t *testing.T) {
+	h := highlight(t, "", "just text\n")
+	if h.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", h.Len())
+	}
+	if got, want := h.Tokens(0), []Token{{0, 9, Plain}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens(0) = %v, want %v", got, want)
+	}
+	if got := h.Tokens(5); got != nil {
+		t.Errorf("Tokens past the end = %v, want nil", got)
+	}
+}
+
+func TestHighlighterStatesCarryAcrossLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		text     string
+		row, col int
+		want     Kind
+	}{
+		{"inside a block comment", "Go", "a\n/* start\nmiddle\nend */ b", 2, 0, Comment},
+		{"block comment closes", "Go", "a\n/* start\nmiddle\nend */ b", 3, 0, Comment},
+		{"after the comment closes", "Go", "a\n/* start\nmiddle\nend */ func", 3, 8, Keyword},
+		{"inside a raw string", "Go", "s := `one\nfunc\n` + x", 1, 0, String},
+		{"after the raw string", "Go", "s := `one\nfunc\n` + x", 2, 2, Operator},
+		{"inside a docstring", "Python", "x = \"\"\"\ndef\n\"\"\"\ndef f(): pass", 1, 0, String},
+		{"after the docstring", "Python", "x = \"\"\"\ndef\n\"\"\"\ndef f(): pass", 3, 0, Keyword},
+		{"inside a fence", "Markdown", "```go\n# not a heading\n```\n# heading", 1, 0, String},
+		{"after the fence", "Markdown", "```go\n# not a heading\n```\n# heading", 3, 0, Keyword},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := highlight(t, tt.language, tt.text)
+			if got := kindAt(h.Tokens(tt.row), tt.col); got != tt.want {
+				t.Errorf("kind at %d:%d = %v, want %v; tokens %v", tt.row, tt.col, got, tt.want, h.Tokens(tt.row))
+			}
+		})
+	}
+}
+
+func TestHighlighterReplaceRelexesRowsBelow(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		row     int
+		removed int
+		lines   []string
+	}{
+		{"opening a block comment", "a := 1\nb := 2\nc := 3", 0, 1, []string{"/* a := 1"}},
+		{"closing a block comment", "/* a\nb\nc", 1, 1, []string{"b */"}},
+		{"removing the opening", "/* a\nb\nc */\nd := 4", 0, 1, []string{"a"}},
+		{"removing the closing", "/* a\nb */\nc := 3\nd := 4", 1, 1, []string{"b"}},
+		{"opening a raw string", "a := 1\nb := 2", 0, 1, []string{"a := `1"}},
+		{"adding lines", "a\n/*\nb\n*/\nc", 2, 1, []string{"x", "*/ y", "/* z"}},
+		{"removing lines", "a\n/*\nb\n*/\nc\nd", 1, 3, []string{"/* b"}},
+		{"joining lines", "/* a\nb */\nc", 0, 2, []string{"/* ab */"}},
+		{"at the end", "a\n/* b", 2, 0, []string{"c */ d"}},
+		{"unchanged state", "a := 1\nb := 2\n/* c\nd */", 0, 1, []string{"a := 100"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := highlight(t, "Go", tt.text)
+			h.Replace(tt.row, tt.removed, tt.lines)
+
+			lines := strings.Split(tt.text, "\n")
+			lines = append(lines[:tt.row:tt.row], append(tt.lines, lines[tt.row+tt.removed:]...)...)
+			checkFresh(t, h, strings.Join(lines, "\n"))
+		})
+	}
+}
+
+func TestHighlighterReplaceBlockCommentRows(t *testing.T) {
+	h := highlight(t, "Go", "a := 1\nb := 2\nfunc c()")
+	h.Replace(0, 1, []string{"/* a := 1"})
+	for row := 1; row < 3; row++ {
+		if got := kindAt(h.Tokens(row), 0); got != Comment {
+			t.Errorf("row %d after opening a comment: kind %v, want Comment", row, got)
+		}
+	}
+	h.Replace(1, 1, []string{"b */ := 2"})
+	if got := kindAt(h.Tokens(2), 0); got != Keyword {
+		t.Errorf("row 2 after closing the comment: kind %v, want Keyword", got)
+	}
+}
+
+func TestHighlighterSetTextKeepsUnchangedLines(t *testing.T) {
+	text := "/* a\nb */\nc := 1\nd := 2"
+	h := highlight(t, "Go", text)
+	for _, next := range []string{
+		"/* a\nb */\nc := 1\nd := 2\n",
+		"x\n/* a\nb */\nc := 1\nd := 2\n",
+		"x\n/* a\nb\nc := 1\nd := 2\n",
+		"",
+		text,
+	} {
+		h.SetText(next)
+		checkFresh(t, h, next)
+	}
+}
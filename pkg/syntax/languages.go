@@ -0,0 +1,190 @@
+package syntax
+
+// Patterns shared by several grammars.
+const (
+	doubleQuoted = `"(?:[^"\\]|\\.)*"?`
+	singleQuoted = `'(?:[^'\\]|\\.)*'?`
+	number       = `0[xX][0-9a-fA-F_]+|0[bB][01_]+|\d[\d_]*(?:\.\d*)?(?:[eE][+-]?\d+)?`
+	identifier   = `[A-Za-z_]\w*`
+	call         = `([A-Za-z_]\w*)(\s*)(\()`
+)
+
+// Rules for /* */ comments spanning lines.
+var blockComment = []Rule{
+	{Pattern: `\*/`, Kind: Comment, Pop: true},
+	{Pattern: `[^*]+|\*`, Kind: Comment},
+}
+
+func init() {
+	Register(&Grammar{
+		Name:       "Go",
+		Extensions: []string{".go"},
+		States: map[string][]Rule{
+			rootState: {
+				{Pattern: `//.*`, Kind: Comment},
+				{Pattern: `/\*`, Kind: Comment, Push: "comment"},
+				{Pattern: "`", Kind: String, Push: "raw"},
+				{Pattern: doubleQuoted, Kind: String},
+				{Pattern: singleQuoted, Kind: String},
+				{Pattern: `\b(?:break|case|chan|const|continue|default|defer|else|fallthrough|for|func|go|goto|if|import|interface|map|package|range|return|select|struct|switch|type|var)\b`, Kind: Keyword},
+				{Pattern: `\b(?:any|bool|byte|comparable|complex64|complex128|error|float32|float64|int|int8|int16|int32|int64|rune|string|uint|uint8|uint16|uint32|uint64|uintptr)\b`, Kind: Type},
+				{Pattern: `\b(?:true|false|nil|iota)\b`, Kind: Constant},
+				{Pattern: call, Groups: []Kind{Function, Plain, Operator}},
+				{Pattern: identifier},
+				{Pattern: number, Kind: Number},
+				{Pattern: `[-+*/%&|^<>=!:.~]+`, Kind: Operator},
+			},
+			"comment": blockComment,
+			"raw": {
+				{Pattern: "`", Kind: String, Pop: true},
+				{Pattern: "[^`]+", Kind: String},
+			},
+		},
+	})
+
+	Register(&Grammar{
+		Name:       "JSON",
+		Extensions: []string{".json", ".jsonc", ".geojson"},
+		States: map[string][]Rule{
+			rootState: {
+				{Pattern: `//.*`, Kind: Comment},
+				{Pattern: `/\*`, Kind: Comment, Push: "comment"},
+				{Pattern: `(` + doubleQuoted + `)(\s*)(:)`, Groups: []Kind{Property, Plain, Operator}},
+				{Pattern: doubleQuoted, Kind: String},
+				{Pattern: `\b(?:true|false|null)\b`, Kind: Constant},
+				{Pattern: `-?` + number, Kind: Number},
+				{Pattern: `[{}\[\],]`, Kind: Operator},
+			},
+			"comment": blockComment,
+		},
+	})
+
+	Register(&Grammar{
+		Name:       "YAML",
+		Extensions: []string{".yaml", ".yml"},
+		States: map[string][]Rule{
+			rootState: {
+				{Pattern: `(?:---|\.\.\.)(?:\s|$)`, Kind: Keyword, LineStart: true},
+				{Pattern: `(\s*)((?:-\s+)?)("[^"]*"|'[^']*'|[^\s#:'"][^#:]*?)(\s*:)(?:\s|$)`, Groups: []Kind{Plain, Operator, Property, Operator}, LineStart: true},
+				{Pattern: `\s*-(?:\s|$)`, Kind: Operator, LineStart: true},
+				{Pattern: `#.*`, Kind: Comment},
+				{Pattern: doubleQuoted, Kind: String},
+				{Pattern: singleQuoted, Kind: String},
+				{Pattern: `[&*][\w-]+|![\w!/.-]*`, Kind: Type},
+				{Pattern: `\b(?:true|false|yes|no|on|off|null)\b|~`, Kind: Constant},
+				{Pattern: `[-+]?` + number + `\b`, Kind: Number},
+				{Pattern: `[|>][-+]?\s*$`, Kind: Operator},
+				{Pattern: `[^\s#]+`},
+			},
+		},
+	})
+
+	Register(&Grammar{
+		Name:         "Shell",
+		Extensions:   []string{".sh", ".bash", ".zsh", ".ksh"},
+		Filenames:    []string{".bashrc", ".bash_profile", ".zshrc", ".profile", "PKGBUILD"},
+		Interpreters: []string{"sh", "bash", "zsh", "ksh", "dash"},
+		States: map[string][]Rule{
+			rootState: {
+				{Pattern: `\$\{[^}]*\}|\$\w+|\$[@*#?$!-]`, Kind: Property},
+				{Pattern: `#.*`, Kind: Comment},
+				{Pattern: `"`, Kind: String, Push: "string"},
+				{Pattern: `'[^']*'?`, Kind: String},
+				{Pattern: `\b(?:if|then|else|elif|fi|for|while|until|do|done|case|esac|function|in|select|return|local|export|readonly|declare|break|continue)\b`, Kind: Keyword},
+				{Pattern: `\b(?:echo|printf|cd|exit|set|unset|source|eval|exec|test|read|shift|trap|alias|true|false)\b`, Kind: Function},
+				{Pattern: `(` + identifier + `)(=)`, Groups: []Kind{Property, Operator}},
+				{Pattern: `[\w./-]+`},
+				{Pattern: `[|&;<>()]+|\[\[?|\]\]?`, Kind: Operator},
+			},
+			"string": {
+				{Pattern: `"`, Kind: String, Pop: true},
+				{Pattern: `\\.`, Kind: String},
+				{Pattern: `\$\{[^}]*\}|\$\w+|\$[@*#?$!-]`, Kind: Property},
+				{Pattern: `[^"\\$]+|\$`, Kind: String},
+			},
+		},
+	})
+
+	Register(&Grammar{
+		Name:         "Python",
+		Extensions:   []string{".py", ".pyw", ".pyi"},
+		Interpreters: []string{"python"},
+		States: map[string][]Rule{
+			rootState: {
+				{Pattern: `#.*`, Kind: Comment},
+				{Pattern: `[rRbBuUfF]{0,2}"""`, Kind: String, Push: "doubleDoc"},
+				{Pattern: `[rRbBuUfF]{0,2}'''`, Kind: String, Push: "singleDoc"},
+				{Pattern: `[rRbBuUfF]{0,2}` + doubleQuoted, Kind: String},
+				{Pattern: `[rRbBuUfF]{0,2}` + singleQuoted, Kind: String},
+				{Pattern: `(def|class)(\s+)(` + identifier + `)`, Groups: []Kind{Keyword, Plain, Function}},
+				{Pattern: `\b(?:and|as|assert|async|await|break|class|continue|def|del|elif|else|except|finally|for|from|global|if|import|in|is|lambda|match|case|nonlocal|not|or|pass|raise|return|try|while|with|yield)\b`, Kind: Keyword},
+				{Pattern: `\b(?:True|False|None|self|cls)\b`, Kind: Constant},
+				{Pattern: `\b(?:bool|bytes|dict|float|int|list|object|set|str|tuple|type)\b`, Kind: Type},
+				{Pattern: `@[\w.]+`, Kind: Function},
+				{Pattern: call, Groups: []Kind{Function, Plain, Operator}},
+				{Pattern: identifier},
+				{Pattern: number + `j?`, Kind: Number},
+				{Pattern: `[-+*/%&|^<>=!~:.@]+`, Kind: Operator},
+			},
+			"doubleDoc": {
+				{Pattern: `"""`, Kind: String, Pop: true},
+				{Pattern: `[^"]+|"`, Kind: String},
+			},
+			"singleDoc": {
+				{Pattern: `'''`, Kind: String, Pop: true},
+				{Pattern: `[^']+|'`, Kind: String},
+			},
+		},
+	})
+
+	Register(&Grammar{
+		Name:         "JavaScript",
+		Extensions:   []string{".js", ".mjs", ".cjs", ".jsx", ".ts", ".tsx"},
+		Interpreters: []string{"node", "deno"},
+		States: map[string][]Rule{
+			rootState: {
+				{Pattern: `//.*`, Kind: Comment},
+				{Pattern: `/\*`, Kind: Comment, Push: "comment"},
+				{Pattern: "`", Kind: String, Push: "template"},
+				{Pattern: doubleQuoted, Kind: String},
+				{Pattern: singleQuoted, Kind: String},
+				{Pattern: `\b(?:async|await|break|case|catch|class|const|continue|debugger|default|delete|do|else|export|extends|finally|for|from|function|if|import|in|instanceof|interface|let|new|of|return|static|super|switch|throw|try|type|typeof|var|void|while|with|yield)\b`, Kind: Keyword},
+				{Pattern: `\b(?:true|false|null|undefined|NaN|Infinity|this)\b`, Kind: Constant},
+				{Pattern: `\b(?:any|boolean|never|number|object|string|symbol|unknown)\b`, Kind: Type},
+				{Pattern: call, Groups: []Kind{Function, Plain, Operator}},
+				{Pattern: `[A-Za-z_$][\w$]*`},
+				{Pattern: number + `n?`, Kind: Number},
+				{Pattern: `[-+*/%&|^<>=!~?:.]+`, Kind: Operator},
+			},
+			"comment": blockComment,
+			"template": {
+				{Pattern: "`", Kind: String, Pop: true},
+				{Pattern: `\\.`, Kind: String},
+				{Pattern: `\$\{[^}]*\}`, Kind: Property},
+				{Pattern: "[^`\\\\$]+|\\$", Kind: String},
+			},
+		},
+	})
+
+	Register(&Grammar{
+		Name:       "Markdown",
+		Extensions: []string{".md", ".markdown", ".mdown"},
+		States: map[string][]Rule{
+			rootState: {
+				{Pattern: "\\s*(?:```|~~~).*", Kind: String, Push: "fence", LineStart: true},
+				{Pattern: `#{1,6}(?:\s.*|$)`, Kind: Keyword, LineStart: true},
+				{Pattern: `\s*>.*`, Kind: Comment, LineStart: true},
+				{Pattern: `\s*(?:[-*+]|\d+[.)])\s`, Kind: Operator, LineStart: true},
+				{Pattern: "`[^`]*`?", Kind: String},
+				{Pattern: `\*\*[^*]+\*\*|__[^_]+__`, Kind: Constant},
+				{Pattern: `\*[^*\s][^*]*\*|_[^_\s][^_]*_`, Kind: Type},
+				{Pattern: `!?\[[^\]]*\]\([^)]*\)`, Kind: Function},
+				{Pattern: "[^`*_!\\[]+"},
+			},
+			"fence": {
+				{Pattern: "\\s*(?:```|~~~)\\s*$", Kind: String, Pop: true, LineStart: true},
+				{Pattern: `.+`, Kind: String},
+			},
+		},
+	})
+}
@@ -0,0 +1,185 @@
+// Package syntax splits source text into colored tokens using per-language grammars.
+package syntax
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// Kind classifies a token for coloring.
+type Kind int
+
+// Token kinds, from plain text to the more specific ones a grammar can pick.
+const (
+	Plain Kind = iota
+	Comment
+	Keyword
+	Type
+	Function
+	Constant
+	String
+	Number
+	Operator
+	Property
+)
+
+// Theme color names for each kind, which the active theme resolves to colors.
+const (
+	ColorNameComment  fyne.ThemeColorName = "syntaxComment"
+	ColorNameKeyword  fyne.ThemeColorName = "syntaxKeyword"
+	ColorNameType     fyne.ThemeColorName = "syntaxType"
+	ColorNameFunction fyne.ThemeColorName = "syntaxFunction"
+	ColorNameConstant fyne.ThemeColorName = "syntaxConstant"
+	ColorNameString   fyne.ThemeColorName = "syntaxString"
+	ColorNameNumber   fyne.ThemeColorName = "syntaxNumber"
+	ColorNameOperator fyne.ThemeColorName = "syntaxOperator"
+	ColorNameProperty fyne.ThemeColorName = "syntaxProperty"
+)
+
+// ColorName returns the theme color for tokens of this kind.
+func (k Kind) ColorName() fyne.ThemeColorName {
+	switch k {
+	case Comment:
+		return ColorNameComment
+	case Keyword:
+		return ColorNameKeyword
+	case Type:
+		return ColorNameType
+	case Function:
+		return ColorNameFunction
+	case Constant:
+		return ColorNameConstant
+	case String:
+		return ColorNameString
+	case Number:
+		return ColorNameNumber
+	case Operator:
+		return ColorNameOperator
+	case Property:
+		return ColorNameProperty
+	}
+	return theme.ColorNameForeground
+}
+
+// Token is a run of one kind of text, as byte offsets within its line.
+type Token struct {
+	Start, End int
+	Kind       Kind
+}
+
+// Rule matches one kind of token in a grammar state.
+type Rule struct {
+	// Pattern is a regular expression matched where the previous token ended.
+	Pattern string
+	// Kind colors the whole match, unless Groups is set.
+	Kind Kind
+	// Groups colors each capture group of the match instead.
+	Groups []Kind
+	// LineStart only tries the rule at the start of a line.
+	LineStart bool
+	// Push enters another state after the match; Pop returns to the previous one.
+	Push string
+	Pop  bool
+
+	re *regexp.Regexp
+}
+
+// Grammar describes how to tokenize a language.
+type Grammar struct {
+	// Name is shown to the user.
+	Name string
+	// Extensions and Filenames pick the grammar by file name.
+	Extensions []string
+	Filenames  []string
+	// Interpreters pick the grammar from a "#!" line.
+	Interpreters []string
+	// States lists the rules of each state, starting in "root".
+	States map[string][]Rule
+}
+
+var grammars []*Grammar
+
+// Register compiles a grammar's rules and makes it available to Detect.
+// It panics on an invalid pattern, as grammars are written into the program.
+func Register(g *Grammar) {
+	for name, rules := range g.States {
+		for i := range rules {
+			rules[i].re = regexp.MustCompile(`^(?:` + rules[i].Pattern + `)`)
+		}
+		g.States[name] = rules
+	}
+	grammars = append(grammars, g)
+}
+
+// Languages returns the registered grammars.
+func Languages() []*Grammar {
+	return grammars
+}
+
+// Lookup returns the registered grammar called name, or nil.
+func Lookup(name string) *Grammar {
+	for _, g := range grammars {
+		if strings.EqualFold(g.Name, name) {
+			return g
+		}
+	}
+	return nil
+}
+
+// Detect picks a grammar for a file from its name, or from the interpreter on
+// its first line. It returns nil for plain text.
+func Detect(filename, firstLine string) *Grammar {
+	base := path.Base(strings.ReplaceAll(filename, `\`, "/"))
+	ext := strings.ToLower(path.Ext(base))
+	for _, g := range grammars {
+		for _, name := range g.Filenames {
+			if base == name {
+				return g
+			}
+		}
+		for _, e := range g.Extensions {
+			if ext != "" && ext == e {
+				return g
+			}
+		}
+	}
+
+	interpreter := shebang(firstLine)
+	if interpreter == "" {
+		return nil
+	}
+	for _, g := range grammars {
+		for _, name := range g.Interpreters {
+			if interpreter == name {
+				return g
+			}
+		}
+	}
+	return nil
+}
+
+// Return the interpreter named by a "#!" line, without any version number.
+func shebang(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	name := path.Base(fields[0])
+	if name == "env" {
+		name = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				name = path.Base(field)
+				break
+			}
+		}
+	}
+	return strings.TrimRight(name, "0123456789.")
+}
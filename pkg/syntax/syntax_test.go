@@ -0,0 +1,48 @@
+package syntax
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		filename  string
+		firstLine string
+		want      string
+	}{
+		{"extension", "main.go", "", "Go"},
+		{"extension in any case", "MAIN.GO", "", "Go"},
+		{"extension in a folder", "src/app/index.tsx", "", "JavaScript"},
+		{"windows path", `C:\work\tool.py`, "", "Python"},
+		{"file name", ".bashrc", "", "Shell"},
+		{"file name in a folder", "pkg/PKGBUILD", "", "Shell"},
+		{"shebang", "script", "#!/bin/bash", "Shell"},
+		{"shebang through env", "script", "#!/usr/bin/env python3", "Python"},
+		{"shebang through env with options", "script", "#!/usr/bin/env -S node --no-warnings", "JavaScript"},
+		{"shebang with a version", "script", "#!/usr/bin/python3.12", "Python"},
+		{"extension before shebang", "build.go", "#!/bin/sh", "Go"},
+		{"unknown extension", "notes.txt", "", ""},
+		{"comment is not a shebang", "script", "# /bin/sh", ""},
+		{"empty shebang", "script", "#!", ""},
+		{"unknown interpreter", "script", "#!/usr/bin/perl", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if g := Detect(tt.filename, tt.firstLine); g != nil {
+				got = g.Name
+			}
+			if got != tt.want {
+				t.Errorf("Detect(%q, %q) = %q, want %q", tt.filename, tt.firstLine, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	if g := Lookup("python"); g == nil || g.Name != "Python" {
+		t.Errorf("Lookup(python) = %v, want Python", g)
+	}
+	if g := Lookup("Klingon"); g != nil {
+		t.Errorf("Lookup(Klingon) = %v, want nil", g.Name)
+	}
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// waiter finishes a --wait once all of its documents are closed.
+type waiter struct {
+	docs map[*Document]bool
+	done func()
+}
+
+// OpenArgs opens what was asked for on the command line. stdin holds standard
+// input when args.Stdin is set.
+func (ui *UI) OpenArgs(args *handling.Args, stdin string) {
+	var first *Document
+	var waitDocs []*Document
+	for _, location := range args.Paths {
+		doc, err := ui.openLocation(location, args.ReadOnly)
+		if err != nil {
+			dialog.ShowError(err, ui.Window)
+			continue
+		}
+		if doc == nil {
+			continue
+		}
+		waitDocs = append(waitDocs, doc)
+		if first == nil {
+			first = doc
+		}
+	}
+
+	if args.Stdin {
+		first = ui.openText(stdin)
+		waitDocs = append(waitDocs, first)
+	}
+	if args.Wait && len(waitDocs) > 0 {
+		ui.waitFor(waitDocs, ui.Exit)
+	}
+	if first != nil {
+		ui.Tabs.Select(first.Tab)
+		ui.Window.Canvas().Focus(first.Editor)
+	}
+}
+
+// Open a file at a 1-based line and column, or a folder in the explorer, in
+// which case there is no document.
+func (ui *UI) openLocation(location handling.Location, readOnly bool) (*Document, error) {
+	info, err := os.Stat(location.Path)
+	if err == nil && info.IsDir() {
+		ui.OpenFolder(location.Path)
+		return nil, nil
+	}
+
+	var doc *Document
+	if errors.Is(err, fs.ErrNotExist) {
+		// Like other editors, a missing file opens empty and is created on save.
+		if doc = ui.findDocument(storage.NewFileURI(location.Path)); doc == nil {
+			doc = ui.emptyDocument()
+			ui.loadDocument(doc, storage.NewFileURI(location.Path), "")
+		}
+	} else if doc, err = ui.OpenPath(location.Path); err != nil {
+		return nil, err
+	}
+
+	doc.setReadOnly(readOnly || doc.Large && ui.largeFilesReadOnly())
+	ui.setDirty(doc, doc.Dirty)
+	if location.Line > 0 {
+		doc.Editor.GoTo(location.Line-1, max(location.Column-1, 0))
+	}
+	return doc, nil
+}
+
+// Open text in an untitled buffer, unsaved unless it is empty.
+func (ui *UI) openText(content string) *Document {
+	doc := ui.emptyDocument()
+	doc.Editor.Load(content)
+	ui.setDirty(doc, content != "")
+	ui.documentSelected(doc)
+	return doc
+}
+
+// Call done once every one of docs is closed.
+func (ui *UI) waitFor(docs []*Document, done func()) {
+	w := &waiter{docs: map[*Document]bool{}, done: done}
+	for _, doc := range docs {
+		w.docs[doc] = true
+	}
+
+	ui.waitLock.Lock()
+	ui.waiting = append(ui.waiting, w)
+	ui.waitLock.Unlock()
+}
+
+// Stop waiting for a closed document, finishing the waits it was the last one of.
+func (ui *UI) stopWaiting(doc *Document) {
+	var finished []*waiter
+	ui.waitLock.Lock()
+	waiting := ui.waiting[:0]
+	for _, w := range ui.waiting {
+		delete(w.docs, doc)
+		if len(w.docs) == 0 {
+			finished = append(finished, w)
+		} else {
+			waiting = append(waiting, w)
+		}
+	}
+	ui.waiting = waiting
+	ui.waitLock.Unlock()
+
+	for _, w := range finished {
+		w.done()
+	}
+}
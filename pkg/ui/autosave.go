@@ -0,0 +1,252 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+const (
+	autosave_mode    = "autosave_mode"
+	autosave_seconds = "autosave_seconds"
+)
+
+// Autosave modes.
+const (
+	AutosaveOff      = "off"
+	AutosaveIdle     = "idle"
+	AutosaveFocus    = "focus"
+	AutosaveInterval = "interval"
+)
+
+// autosaveModes lists the modes in the order they are offered, with their labels.
+var autosaveModes = []struct{ Mode, Label string }{
+	{AutosaveOff, "Off"},
+	{AutosaveIdle, "After a pause in typing"},
+	{AutosaveFocus, "When the window loses focus"},
+	{AutosaveInterval, "At a fixed interval"},
+}
+
+// Autosave periodically writes modified documents to disk.
+// Documents with a file are saved in place, untitled ones go to the recovery directory.
+type Autosave struct {
+	ui *UI
+	// Mode selects when autosave runs.
+	Mode string
+	// Delay is the idle time or interval between saves.
+	Delay time.Duration
+
+	lock   sync.Mutex
+	idle   *time.Timer
+	ticker *time.Ticker
+	stop   chan struct{}
+}
+
+// NewAutosave loads the saved autosave settings and starts autosaving.
+func NewAutosave(ui *UI) *Autosave {
+	as := &Autosave{ui: ui}
+	mode := ui.App.Preferences().StringWithFallback(autosave_mode, AutosaveOff)
+	seconds := ui.App.Preferences().IntWithFallback(autosave_seconds, 30)
+
+	// Focus loss is reported by the app lifecycle, so hook it once here.
+	ui.App.Lifecycle().SetOnExitedForeground(func() {
+		if as.Mode == AutosaveFocus {
+			as.SaveAll()
+		}
+	})
+
+	as.Apply(mode, seconds)
+	return as
+}
+
+// Apply switches to the given mode and delay, persisting them in preferences.
+func (as *Autosave) Apply(mode string, seconds int) {
+	if seconds < 1 {
+		seconds = 1
+	}
+	as.ui.App.Preferences().SetString(autosave_mode, mode)
+	as.ui.App.Preferences().SetInt(autosave_seconds, seconds)
+
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	as.stopLocked()
+	as.Mode = mode
+	as.Delay = time.Duration(seconds) * time.Second
+
+	if mode == AutosaveInterval {
+		as.ticker = time.NewTicker(as.Delay)
+		as.stop = make(chan struct{})
+		go func(ticker *time.Ticker, stop chan struct{}) {
+			for {
+				select {
+				case <-ticker.C:
+					fyne.Do(as.SaveAll)
+				case <-stop:
+					return
+				}
+			}
+		}(as.ticker, as.stop)
+	}
+}
+
+// Touched restarts the idle countdown after a document changes.
+func (as *Autosave) Touched() {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+
+	if as.Mode != AutosaveIdle {
+		return
+	}
+	if as.idle != nil {
+		as.idle.Stop()
+	}
+	as.idle = time.AfterFunc(as.Delay, func() { fyne.Do(as.SaveAll) })
+}
+
+// SaveAll writes every modified document. It takes a snapshot of each on the
+// UI thread and writes it in the background; a document is only marked saved
+// if it was not edited meanwhile.
+func (as *Autosave) SaveAll() {
+	for _, doc := range as.ui.Documents {
+		if !doc.Dirty || doc.autosaving {
+			continue
+		}
+		buffer, edits, uri := doc.Editor.Buffer.Snapshot(), doc.edits, doc.URI
+
+		if uri != nil {
+			doc.autosaving = true
+			go func() {
+				err := handling.SaveBuffer(uri, buffer)
+				fyne.Do(func() {
+					defer as.autosaved(doc)
+					if err != nil {
+						fyne.LogError("Failed to autosave "+uri.String(), err)
+						return
+					}
+					if doc.edits == edits && doc.URI == uri {
+						as.ui.setDirty(doc, false)
+					}
+				})
+			}()
+			continue
+		}
+
+		dir, err := as.RecoveryDir()
+		if err != nil {
+			fyne.LogError("Failed to find recovery directory", err)
+			return
+		}
+		doc.autosaving = true
+		go func(name string) {
+			uri, err := handling.SaveFileInDir(dir, name, buffer.String())
+			fyne.Do(func() {
+				defer as.autosaved(doc)
+				if err != nil {
+					fyne.LogError("Failed to write recovery file", err)
+					return
+				}
+				doc.RecoveryURI = uri
+				// The document may have been saved or closed while it was written.
+				if doc.URI != nil || !slices.Contains(as.ui.Documents, doc) {
+					as.discardRecovery(doc)
+				}
+			})
+		}(doc.recoveryName)
+	}
+}
+
+// Finish autosaving doc, making the saves that waited for it.
+func (as *Autosave) autosaved(doc *Document) {
+	doc.autosaving = false
+	saves := doc.waitingSaves
+	doc.waitingSaves = nil
+	for _, save := range saves {
+		save()
+	}
+}
+
+// RecoveryDir returns where untitled documents are autosaved.
+func (as *Autosave) RecoveryDir() (fyne.URI, error) {
+	return storage.Child(as.ui.App.Storage().RootURI(), "recovery")
+}
+
+// Stop cancels any pending autosave.
+func (as *Autosave) Stop() {
+	as.lock.Lock()
+	defer as.lock.Unlock()
+	as.stopLocked()
+}
+
+func (as *Autosave) stopLocked() {
+	if as.idle != nil {
+		as.idle.Stop()
+		as.idle = nil
+	}
+	if as.ticker != nil {
+		as.ticker.Stop()
+		close(as.stop)
+		as.ticker = nil
+	}
+}
+
+// Remove a document's recovery file once it is no longer needed.
+func (as *Autosave) discardRecovery(doc *Document) {
+	if doc.RecoveryURI == nil {
+		return
+	}
+	if err := storage.Delete(doc.RecoveryURI); err != nil {
+		fyne.LogError("Failed to remove recovery file", err)
+	}
+	doc.RecoveryURI = nil
+}
+
+// OpenAutosaveSettings shows a form to change when autosave runs.
+func (ui *UI) OpenAutosaveSettings() {
+	labels := make([]string, len(autosaveModes))
+	selected := autosaveModes[0].Label
+	for i, m := range autosaveModes {
+		labels[i] = m.Label
+		if m.Mode == ui.Autosave.Mode {
+			selected = m.Label
+		}
+	}
+
+	modeSelect := widget.NewSelect(labels, nil)
+	modeSelect.SetSelected(selected)
+
+	secondsEntry := widget.NewEntry()
+	secondsEntry.SetText(strconv.Itoa(int(ui.Autosave.Delay / time.Second)))
+	secondsEntry.Validator = func(s string) error {
+		if n, err := strconv.Atoi(s); err != nil || n < 1 {
+			return fmt.Errorf("enter a whole number of seconds")
+		}
+		return nil
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Autosave", modeSelect),
+		widget.NewFormItem("Seconds", secondsEntry),
+	}
+	dialog.ShowForm("Autosave Settings", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		mode := AutosaveOff
+		for _, m := range autosaveModes {
+			if m.Label == modeSelect.Selected {
+				mode = m.Mode
+			}
+		}
+		seconds, _ := strconv.Atoi(secondsEntry.Text)
+		ui.Autosave.Apply(mode, seconds)
+	}, ui.Window)
+}
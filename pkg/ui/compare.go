@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/syntax"
+)
+
+// Compare shows two texts side by side in a window of its own, marking the
+// lines and words that differ and scrolling both together. Open documents
+// are shown through views of their editors, so merged changes land in them.
+type Compare struct {
+	ui *UI
+
+	Window      fyne.Window
+	Left, Right *handling.Editor
+	Status      *widget.Label
+
+	mergeLeft, mergeRight *widget.Button
+
+	hunks   []handling.Hunk
+	current int
+	// syncing is set while one side scrolls to follow the other.
+	syncing bool
+	timer   *time.Timer
+}
+
+// NewCompare opens a window comparing the left and right editors, titled by leftName and rightName.
+func NewCompare(ui *UI, left, right *handling.Editor, leftName, rightName string) *Compare {
+	c := &Compare{
+		ui:     ui,
+		Window: ui.App.NewWindow(fmt.Sprintf("Compare %s ↔ %s", leftName, rightName)),
+		Left:   left,
+		Right:  right,
+		Status: widget.NewLabel(""),
+	}
+	c.mergeLeft = widget.NewButton("← Merge", func() { c.merge(false) })
+	c.mergeRight = widget.NewButton("Merge →", func() { c.merge(true) })
+	if left.ReadOnly {
+		c.mergeLeft.Disable()
+	}
+	if right.ReadOnly {
+		c.mergeRight.Disable()
+	}
+
+	left.OnEdited = c.scheduleDiff
+	right.OnEdited = c.scheduleDiff
+	left.OnScrolled = func() { c.follow(left, right, false) }
+	right.OnScrolled = func() { c.follow(right, left, true) }
+
+	toolbar := container.NewHBox(
+		widget.NewButton("Previous Change", func() { c.showHunk(c.current - 1) }),
+		widget.NewButton("Next Change", func() { c.showHunk(c.current + 1) }),
+		c.mergeLeft,
+		c.mergeRight,
+	)
+	panes := container.NewHSplit(
+		container.NewBorder(widget.NewLabel(leftName), nil, nil, nil, left),
+		container.NewBorder(widget.NewLabel(rightName), nil, nil, nil, right),
+	)
+	c.Window.SetContent(container.NewBorder(toolbar, c.Status, nil, nil, panes))
+	c.Window.SetOnClosed(c.close)
+	c.Window.Resize(fyne.NewSize(1000, 700))
+	ui.compares = append(ui.compares, c)
+
+	c.diff()
+	c.showHunk(0)
+	return c
+}
+
+// Show opens the window.
+func (c *Compare) Show() {
+	c.Window.Show()
+}
+
+// Compare the texts again once typing settles, waiting longer for large ones.
+func (c *Compare) scheduleDiff() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if c.Left.Buffer.Len()+c.Right.Buffer.Len() < largeSearchText {
+		c.diff()
+		return
+	}
+	c.timer = time.AfterFunc(searchDelay, func() { fyne.Do(c.diff) })
+}
+
+// Find the hunks between the two sides and mark them.
+func (c *Compare) diff() {
+	left, right := handling.SplitLines(c.Left.Text()), handling.SplitLines(c.Right.Text())
+	c.hunks = handling.DiffLines(left, right)
+
+	removed, added := tint(theme.ColorNameError, 0x30), tint(theme.ColorNameSuccess, 0x30)
+	removedWords, addedWords := tint(theme.ColorNameError, 0x60), tint(theme.ColorNameSuccess, 0x60)
+	var leftMarks, rightMarks []handling.Mark
+	for _, h := range c.hunks {
+		leftMarks = append(leftMarks, lineMark(c.Left.Buffer, h.LeftStart, h.LeftEnd, removed))
+		rightMarks = append(rightMarks, lineMark(c.Right.Buffer, h.RightStart, h.RightEnd, added))
+
+		// Lines changed in place also mark the words that changed.
+		for i := 0; i < min(h.LeftEnd-h.LeftStart, h.RightEnd-h.RightStart); i++ {
+			leftRow, rightRow := h.LeftStart+i, h.RightStart+i
+			leftSpans, rightSpans := handling.DiffLine(left[leftRow], right[rightRow])
+			leftMarks = append(leftMarks, spanMarks(c.Left.Buffer.LineStart(leftRow), leftSpans, removedWords)...)
+			rightMarks = append(rightMarks, spanMarks(c.Right.Buffer.LineStart(rightRow), rightSpans, addedWords)...)
+		}
+	}
+	c.Left.SetMarks(leftMarks)
+	c.Right.SetMarks(rightMarks)
+	c.current = max(0, min(c.current, len(c.hunks)-1))
+	c.showStatus()
+}
+
+// Scroll both sides to hunk i.
+func (c *Compare) showHunk(i int) {
+	if i < 0 || i >= len(c.hunks) {
+		return
+	}
+	c.current = i
+	c.Left.ScrollTo(float32(max(0, c.hunks[i].LeftStart-3)), 0)
+	c.showStatus()
+}
+
+// Say which hunk is current.
+func (c *Compare) showStatus() {
+	if len(c.hunks) == 0 {
+		c.Status.SetText("No differences")
+		return
+	}
+	c.Status.SetText(fmt.Sprintf("Change %d of %d", c.current+1, len(c.hunks)))
+}
+
+// Copy the current hunk from the left side to the right one, or the other way
+// around, as a single undoable edit.
+func (c *Compare) merge(toRight bool) {
+	if c.current >= len(c.hunks) {
+		return
+	}
+	to := c.Left
+	if toRight {
+		to = c.Right
+	}
+	if to.ReadOnly {
+		return
+	}
+
+	left, right := handling.SplitLines(c.Left.Text()), handling.SplitLines(c.Right.Text())
+	to.SetText(strings.Join(c.hunks[c.current].Merge(left, right, toRight), "\n"))
+	c.diff()
+}
+
+// Scroll one side to the lines beside those in view on the other.
+func (c *Compare) follow(from, to *handling.Editor, fromRight bool) {
+	if c.syncing {
+		return
+	}
+	c.syncing = true
+	row, x := from.ScrollPosition()
+	to.ScrollTo(handling.MapRow(c.hunks, row, fromRight), x)
+	c.syncing = false
+}
+
+// Stop following documents once the window is closed.
+func (c *Compare) close() {
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.Left.CloseView()
+	c.Right.CloseView()
+	c.ui.compares = slices.DeleteFunc(c.ui.compares, func(other *Compare) bool { return other == c })
+}
+
+// Whether either side is a view of the document's editor.
+func (c *Compare) shows(doc *Document) bool {
+	views := doc.Editor.Views()
+	return slices.Contains(views, c.Left) || slices.Contains(views, c.Right)
+}
+
+// Close the compare windows showing a document, which must not outlive it.
+func (ui *UI) closeCompares(doc *Document) {
+	for _, c := range slices.Clone(ui.compares) {
+		if c.shows(doc) {
+			c.Window.Close()
+		}
+	}
+}
+
+// Mark rows start up to end, or a rule where they would be when there are none.
+func lineMark(buffer *handling.Buffer, start, end int, fill color.Color) handling.Mark {
+	if start == end {
+		pos := buffer.Len()
+		if start < buffer.Lines() {
+			pos = buffer.LineStart(start)
+		}
+		return handling.Mark{Start: pos, End: pos, Line: true, Color: fill}
+	}
+	last := end - 1
+	return handling.Mark{
+		Start: buffer.LineStart(start),
+		End:   buffer.LineStart(last) + len(buffer.Line(last)),
+		Line:  true,
+		Color: fill,
+	}
+}
+
+// Mark spans of the line starting at offset.
+func spanMarks(offset int, spans []handling.Span, fill color.Color) []handling.Mark {
+	marks := make([]handling.Mark, len(spans))
+	for i, span := range spans {
+		marks[i] = handling.Mark{Start: offset + span.Start, End: offset + span.End, Color: fill}
+	}
+	return marks
+}
+
+// A see-through version of a theme color, to lay behind text.
+func tint(name fyne.ThemeColorName, alpha uint8) color.Color {
+	c := color.NRGBAModel.Convert(themeColor(name)).(color.NRGBA)
+	c.A = alpha
+	return c
+}
+
+// Compare the active document with its file on disk.
+func (ui *UI) compareWithSaved() {
+	doc := ui.ActiveDocument()
+	if doc == nil || !ui.canCompare(doc) {
+		return
+	}
+	if doc.URI == nil {
+		dialog.ShowInformation("Compare", "Save the document first to compare it with its file.", ui.Window)
+		return
+	}
+	content, err := handling.ReadFile(doc.URI)
+	if err != nil {
+		dialog.ShowError(err, ui.Window)
+		return
+	}
+
+	saved := handling.NewEditor()
+	saved.Load(content)
+	saved.ReadOnly = true
+	firstLine, _, _ := strings.Cut(content, "\n")
+	saved.Highlighter = syntax.NewHighlighter(syntax.Detect(doc.Name(), firstLine))
+	saved.Highlighter.SetText(content)
+	saved.SetLineNumbers(ui.lineNumbers())
+	NewCompare(ui, saved, doc.Editor.NewView(), doc.Name()+" (saved)", doc.Name()).Show()
+}
+
+// Ask for another open document and compare the active one with it.
+func (ui *UI) compareWithDocument() {
+	doc := ui.ActiveDocument()
+	if doc == nil || !ui.canCompare(doc) {
+		return
+	}
+	var others []*Document
+	var names []string
+	for _, other := range ui.Documents {
+		if other != doc {
+			others = append(others, other)
+			names = append(names, other.Name())
+		}
+	}
+	if len(others) == 0 {
+		dialog.ShowInformation("Compare", "Open another document to compare with.", ui.Window)
+		return
+	}
+
+	choice := widget.NewSelect(names, nil)
+	choice.SetSelectedIndex(0)
+	dialog.ShowForm("Compare "+doc.Name()+" With", "Compare", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Document", choice),
+	}, func(ok bool) {
+		if ok {
+			ui.compareDocuments(doc, others[choice.SelectedIndex()])
+		}
+	}, ui.Window)
+}
+
+// Ask for two files, open them and compare them.
+func (ui *UI) compareFiles() {
+	ui.chooseCompareFile(func(left *Document) {
+		ui.chooseCompareFile(func(right *Document) { ui.compareDocuments(left, right) })
+	})
+}
+
+// Ask for a file to compare and open it, handing over its document.
+func (ui *UI) chooseCompareFile(onChosen func(doc *Document)) {
+	open := func(uri fyne.URI) {
+		doc, err := ui.OpenPath(uri.Path())
+		if err != nil {
+			dialog.ShowError(err, ui.Window)
+			return
+		}
+		if ui.canCompare(doc) {
+			onChosen(doc)
+		}
+	}
+	handling.OpenFile(ui.Window, func(uri fyne.URI, _ string) { open(uri) }, open)
+}
+
+// Compare two open documents.
+func (ui *UI) compareDocuments(left, right *Document) {
+	if !ui.canCompare(left) || !ui.canCompare(right) {
+		return
+	}
+	NewCompare(ui, left.Editor.NewView(), right.Editor.NewView(), left.Name(), right.Name()).Show()
+}
+
+// Whether a document is small enough to compare, explaining when it is not.
+func (ui *UI) canCompare(doc *Document) bool {
+	if doc.Large {
+		dialog.ShowInformation("Compare", doc.Name()+" is too large to compare.", ui.Window)
+		return false
+	}
+	return true
+}
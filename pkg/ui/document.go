@@ -0,0 +1,403 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// AppTitle is the window title shown next to the active file name.
+const AppTitle = "Leda Text Editor"
+
+// Document holds the state of a single open tab.
+type Document struct {
+	// Editor retains raw text in an edit buffer, including its cursor position and undo history.
+	Editor *handling.Editor
+	// Split is a second view of the same text while the editor is split, nil otherwise.
+	Split *handling.Editor
+	// URI is the file the document was loaded from, nil while untitled.
+	URI fyne.URI
+	// Dirty indicates the buffer differs from what is on disk.
+	Dirty bool
+	// Tab is the tab item showing this document.
+	Tab *container.TabItem
+	// Title is shown in the tab while the document is untitled.
+	Title string
+	// Large marks a file paged in from disk, without preview or highlighting.
+	Large bool
+	// RecoveryURI is where autosave last wrote the untitled document, if anywhere.
+	RecoveryURI fyne.URI
+
+	// recoveryName names the document's file in the recovery directory.
+	recoveryName string
+	// edits counts changes to the text, so a save can tell whether it saved the latest.
+	edits int
+	// autosaving is set while autosave writes the document in the background,
+	// and waitingSaves are the saves held back until it is done.
+	autosaving   bool
+	waitingSaves []func()
+
+	// git is the repository holding the file, if any, and gitHead the file's
+	// text at the last commit when gitTracked is set. gitHunks are the changes since.
+	git        *handling.Repository
+	gitHead    string
+	gitTracked bool
+	gitHunks   []handling.Hunk
+	gitTimer   *time.Timer
+
+	// Search state.
+	// Matches hold the ranges of all occurrences.
+	Matches []handling.Match
+	// CurrentMatchIdx keeps track of current match.
+	CurrentMatchIdx int
+}
+
+// Name returns the file name of the document, or its untitled placeholder.
+func (doc *Document) Name() string {
+	if doc.URI != nil {
+		return doc.URI.Name()
+	}
+	return doc.Title
+}
+
+// DisplayName returns the name prefixed with a marker while there are unsaved changes,
+// and flagged when the document is read-only.
+func (doc *Document) DisplayName() string {
+	name := doc.Name()
+	if doc.Editor.ReadOnly {
+		name += " (read-only)"
+	}
+	if doc.Dirty {
+		return "• " + name
+	}
+	return name
+}
+
+// Make every editor of the document read-only or not.
+func (doc *Document) setReadOnly(readOnly bool) {
+	for _, view := range doc.Editor.Views() {
+		view.ReadOnly = readOnly
+	}
+}
+
+// NewDocument creates an empty untitled document and selects its tab.
+func (ui *UI) NewDocument() *Document {
+	ui.untitledCount++
+	title := "Untitled"
+	if ui.untitledCount > 1 {
+		title = fmt.Sprintf("Untitled %d", ui.untitledCount)
+	}
+
+	doc := &Document{
+		Editor:          handling.NewEditor(),
+		Title:           title,
+		recoveryName:    fmt.Sprintf("%s-%d.txt", time.Now().Format("20060102-150405"), ui.untitledCount),
+		Matches:         []handling.Match{},
+		CurrentMatchIdx: -1,
+	}
+	doc.Editor.SetLineNumbers(ui.lineNumbers())
+	doc.Tab = container.NewTabItem(doc.Name(), doc.Editor)
+
+	// Update Markdown Preview whenever text changes.
+	doc.Editor.OnChanged = func() {
+		doc.edits++
+		ui.setDirty(doc, true)
+		ui.Autosave.Touched()
+		if doc == ui.ActiveDocument() {
+			ui.UpdateCounts(doc.Editor.Buffer)
+		}
+		if doc.Large {
+			ui.clearMatches(doc)
+			return
+		}
+		ui.highlight(doc)
+		ui.scheduleGitChanges(doc)
+		if doc == ui.ActiveDocument() {
+			ui.RenderMarkdown(doc.Editor.Text())
+		}
+		if doc == ui.ActiveDocument() && ui.SidebarVisible && ui.SearchTermEntry.Text != "" {
+			ui.scheduleSearch(false)
+		} else {
+			ui.clearMatches(doc)
+		}
+	}
+
+	// Keep the preview beside the cursor.
+	doc.Editor.OnCursorChanged = func() {
+		if doc == ui.ActiveDocument() {
+			ui.syncPreview()
+		}
+	}
+
+	ui.Documents = append(ui.Documents, doc)
+	ui.Tabs.Append(doc.Tab)
+	ui.Tabs.Select(doc.Tab)
+	return doc
+}
+
+// OpenDocument shows loaded file content, reusing an empty untitled tab when possible.
+func (ui *UI) OpenDocument(uri fyne.URI, content string) {
+	// Switch to the file if it is already open, reloading it unless that loses edits.
+	if doc := ui.findDocument(uri); doc != nil {
+		ui.Tabs.Select(doc.Tab)
+		ui.confirmUnsaved(doc, func() { ui.loadDocument(doc, uri, content) })
+		return
+	}
+
+	ui.loadDocument(ui.emptyDocument(), uri, content)
+}
+
+// OpenPath loads a file from disk into a tab, or switches to its tab when it is already open.
+func (ui *UI) OpenPath(path string) (*Document, error) {
+	uri := storage.NewFileURI(path)
+	if doc := ui.findDocument(uri); doc != nil {
+		ui.Tabs.Select(doc.Tab)
+		return doc, nil
+	}
+
+	if handling.IsLargeFile(uri) {
+		return ui.openLargeFile(path)
+	}
+	content, err := handling.ReadFile(uri)
+	if err != nil {
+		return nil, err
+	}
+	doc := ui.emptyDocument()
+	ui.loadDocument(doc, uri, content)
+	return doc, nil
+}
+
+// Return the active document if it is untitled and empty, otherwise a new one.
+func (ui *UI) emptyDocument() *Document {
+	doc := ui.ActiveDocument()
+	if doc == nil || doc.URI != nil || doc.Dirty || doc.Editor.Buffer.Len() != 0 {
+		doc = ui.NewDocument()
+	}
+	return doc
+}
+
+// Replace a document's content with what was read from uri.
+func (ui *UI) loadDocument(doc *Document, uri fyne.URI, content string) {
+	doc.URI = uri
+	// A large file shown read-only before is editable again; callers asking for
+	// read-only set it afterwards.
+	doc.Large = false
+	doc.setReadOnly(false)
+	doc.Editor.Load(content)
+	ui.highlight(doc)
+	ui.refreshGit(doc)
+	ui.setDirty(doc, false)
+	ui.documentSelected(doc)
+}
+
+// SaveDocument writes the document back to its file, asking for a location while untitled.
+func (ui *UI) SaveDocument(doc *Document) {
+	ui.saveDocument(doc, nil)
+}
+
+// SaveDocumentAs asks for a new location and saves the document there.
+func (ui *UI) SaveDocumentAs(doc *Document) {
+	ui.saveDocumentAs(doc, nil)
+}
+
+// Save a document, calling onSaved once it is written.
+func (ui *UI) saveDocument(doc *Document, onSaved func()) {
+	if doc.URI == nil {
+		ui.saveDocumentAs(doc, onSaved)
+		return
+	}
+
+	// An autosave still writing an older snapshot would land after this save.
+	if doc.autosaving {
+		doc.waitingSaves = append(doc.waitingSaves, func() { ui.saveDocument(doc, onSaved) })
+		return
+	}
+	if err := handling.SaveBuffer(doc.URI, doc.Editor.Buffer); err != nil {
+		dialog.ShowError(err, ui.Window)
+		return
+	}
+	ui.documentSaved(doc, doc.URI, onSaved)
+}
+
+// Save a document to a new location, calling onSaved once it is written.
+func (ui *UI) saveDocumentAs(doc *Document, onSaved func()) {
+	// The file dialog empties the file it saves to, which may be the one a large file pages from.
+	if err := doc.Editor.Buffer.Detach(); err != nil {
+		dialog.ShowError(err, ui.Window)
+		return
+	}
+	handling.SaveFileAs(ui.Window, doc.Editor.Buffer, func(uri fyne.URI) {
+		ui.documentSaved(doc, uri, onSaved)
+	})
+}
+
+// Record where a document was saved to.
+func (ui *UI) documentSaved(doc *Document, uri fyne.URI, onSaved func()) {
+	doc.URI = uri
+	ui.highlight(doc)
+	ui.refreshGit(doc)
+	ui.setDirty(doc, false)
+	ui.Autosave.discardRecovery(doc)
+	if onSaved != nil {
+		onSaved()
+	}
+}
+
+// Update the modified flag and the markers showing it.
+func (ui *UI) setDirty(doc *Document, dirty bool) {
+	doc.Dirty = dirty
+	if doc.Tab.Text != doc.DisplayName() {
+		doc.Tab.Text = doc.DisplayName()
+		ui.Tabs.Refresh()
+	}
+	if doc == ui.ActiveDocument() {
+		ui.updateTitle()
+	}
+}
+
+// Ask whether to save a modified document before proceed discards it.
+// Nothing happens when the user cancels.
+func (ui *UI) confirmUnsaved(doc *Document, proceed func()) {
+	if !doc.Dirty {
+		proceed()
+		return
+	}
+	ui.Tabs.Select(doc.Tab)
+
+	var prompt *dialog.CustomDialog
+	save := widget.NewButton("Save", func() {
+		prompt.Hide()
+		ui.saveDocument(doc, proceed)
+	})
+	save.Importance = widget.HighImportance
+	discard := widget.NewButton("Discard", func() {
+		prompt.Hide()
+		proceed()
+	})
+	cancel := widget.NewButton("Cancel", func() { prompt.Hide() })
+
+	message := widget.NewLabel(fmt.Sprintf("%s has unsaved changes. Save them first?", doc.Name()))
+	prompt = dialog.NewCustomWithoutButtons("Unsaved Changes", message, ui.Window)
+	prompt.SetButtons([]fyne.CanvasObject{cancel, discard, save})
+	prompt.Show()
+}
+
+// Ask about every modified document in turn, then proceed once all are handled.
+func (ui *UI) confirmAllUnsaved(proceed func()) {
+	ui.confirmUnsavedFrom(0, proceed)
+}
+
+// Ask about the modified documents starting at index i.
+func (ui *UI) confirmUnsavedFrom(i int, proceed func()) {
+	if i >= len(ui.Documents) {
+		proceed()
+		return
+	}
+	ui.confirmUnsaved(ui.Documents[i], func() { ui.confirmUnsavedFrom(i+1, proceed) })
+}
+
+// Exit closes the window once unsaved changes have been dealt with.
+func (ui *UI) Exit() {
+	ui.confirmAllUnsaved(func() {
+		ui.Autosave.Stop()
+		ui.stopJournal()
+		ui.Explorer.Close()
+		ui.stopServing()
+		for _, doc := range ui.Documents {
+			ui.Autosave.discardRecovery(doc)
+		}
+		ui.Window.Close()
+	})
+}
+
+// ActiveDocument returns the document in the selected tab.
+func (ui *UI) ActiveDocument() *Document {
+	return ui.documentForTab(ui.Tabs.Selected())
+}
+
+// CloseDocument removes the document's tab after checking for unsaved changes.
+func (ui *UI) CloseDocument(doc *Document) {
+	ui.confirmUnsaved(doc, func() {
+		ui.removeDocument(doc)
+		ui.stopWaiting(doc)
+		if err := doc.Editor.Buffer.Close(); err != nil {
+			fyne.LogError("Failed to close "+doc.Name(), err)
+		}
+	})
+}
+
+// Remove a document's tab, keeping at least one tab open.
+func (ui *UI) removeDocument(doc *Document) {
+	ui.Autosave.discardRecovery(doc)
+	ui.closeCompares(doc)
+	for i, d := range ui.Documents {
+		if d == doc {
+			ui.Documents = append(ui.Documents[:i], ui.Documents[i+1:]...)
+			break
+		}
+	}
+	ui.Tabs.Remove(doc.Tab)
+
+	if len(ui.Documents) == 0 {
+		ui.NewDocument()
+		return
+	}
+	ui.documentSelected(ui.ActiveDocument())
+}
+
+// Find the document open from uri.
+func (ui *UI) findDocument(uri fyne.URI) *Document {
+	for _, doc := range ui.Documents {
+		if doc.URI != nil && doc.URI.String() == uri.String() {
+			return doc
+		}
+	}
+	return nil
+}
+
+// Find the document shown in a tab.
+func (ui *UI) documentForTab(item *container.TabItem) *Document {
+	for _, doc := range ui.Documents {
+		if doc.Tab == item {
+			return doc
+		}
+	}
+	return nil
+}
+
+// Point the status bar, preview and search sidebar at the selected document.
+func (ui *UI) documentSelected(doc *Document) {
+	if doc == nil {
+		return
+	}
+
+	if doc.Large {
+		ui.RenderMarkdown("*The preview is off for large files.*")
+	} else {
+		ui.RenderMarkdown(doc.Editor.Text())
+	}
+	ui.UpdateCounts(doc.Editor.Buffer)
+	ui.showLanguage(doc)
+	ui.showLargeFile(doc)
+	ui.showGit(doc)
+	ui.MatchList.UnselectAll()
+	ui.MatchList.Refresh()
+	ui.updateSearchResults(doc)
+	ui.updateTitle()
+}
+
+// Show the active document's name in the window title.
+func (ui *UI) updateTitle() {
+	doc := ui.ActiveDocument()
+	if doc == nil {
+		ui.Window.SetTitle(AppTitle)
+		return
+	}
+	ui.Window.SetTitle(doc.DisplayName() + " - " + AppTitle)
+}
@@ -0,0 +1,414 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+	"github.com/fsnotify/fsnotify"
+)
+
+// explorerRefresh is how long filesystem events settle before the tree redraws.
+const explorerRefresh = 200 * time.Millisecond
+
+// Explorer is the project sidebar, showing the files below a folder.
+type Explorer struct {
+	ui *UI
+
+	// Root is the project folder, empty until one is opened.
+	Root string
+	// Tree lists directories and files, identified by their paths.
+	Tree *widget.Tree
+
+	panel    fyne.CanvasObject
+	selected string
+
+	lock     sync.Mutex
+	children map[string][]string
+	dirs     map[string]bool
+	watcher  *fsnotify.Watcher
+	refresh  *time.Timer
+}
+
+// NewExplorer creates the explorer's widgets.
+func NewExplorer(ui *UI) *Explorer {
+	ex := &Explorer{
+		ui:       ui,
+		children: map[string][]string{},
+		dirs:     map[string]bool{},
+	}
+
+	ex.Tree = widget.NewTree(ex.childUIDs, ex.isBranch, ex.createNode, ex.updateNode)
+	ex.Tree.OnSelected = ex.nodeSelected
+
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.FolderOpenIcon(), func() { ui.ChooseFolder() }),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.DocumentCreateIcon(), func() { ex.createEntry(false) }),
+		widget.NewToolbarAction(theme.FolderNewIcon(), func() { ex.createEntry(true) }),
+		widget.NewToolbarAction(theme.DocumentSaveIcon(), func() { ex.renameSelected() }),
+		widget.NewToolbarAction(theme.MailForwardIcon(), func() { ex.moveSelected() }),
+		widget.NewToolbarAction(theme.DeleteIcon(), func() { ex.deleteSelected() }),
+		widget.NewToolbarSpacer(),
+		widget.NewToolbarAction(theme.CancelIcon(), func() { ui.toggleExplorer() }),
+	)
+	ex.panel = container.NewBorder(container.NewVBox(widget.NewLabel("📁 Explorer"), toolbar), nil, nil, nil, ex.Tree)
+	return ex
+}
+
+// Panel returns the explorer's content.
+func (ex *Explorer) Panel() fyne.CanvasObject {
+	return ex.panel
+}
+
+// SetRoot shows the files below folder and starts watching it for changes.
+func (ex *Explorer) SetRoot(folder string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	ex.lock.Lock()
+	if ex.watcher != nil {
+		ex.watcher.Close()
+	}
+	ex.Root = folder
+	ex.watcher = watcher
+	ex.children = map[string][]string{}
+	ex.dirs = map[string]bool{folder: true}
+	ex.lock.Unlock()
+
+	go ex.watch(watcher)
+	ex.selected = ""
+	ex.Tree.Root = folder
+	ex.Tree.UnselectAll()
+	ex.Tree.CloseAllBranches()
+	ex.Tree.Refresh()
+	return nil
+}
+
+// Close stops watching the filesystem.
+func (ex *Explorer) Close() {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	if ex.watcher != nil {
+		ex.watcher.Close()
+		ex.watcher = nil
+	}
+}
+
+// List a directory the first time its branch is shown, watching it from then on.
+func (ex *Explorer) childUIDs(uid widget.TreeNodeID) []widget.TreeNodeID {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	if uid == "" || ex.Root == "" {
+		return nil
+	}
+	if children, ok := ex.children[uid]; ok {
+		return children
+	}
+
+	entries, err := handling.ListDir(uid)
+	if err != nil {
+		fyne.LogError("Failed to list "+uid, err)
+		return nil
+	}
+	children := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		path := filepath.Join(uid, entry.Name())
+		children = append(children, path)
+		ex.dirs[path] = entry.IsDir()
+	}
+	ex.children[uid] = children
+
+	if ex.watcher != nil {
+		if err := ex.watcher.Add(uid); err != nil {
+			fyne.LogError("Failed to watch "+uid, err)
+		}
+	}
+	return children
+}
+
+func (ex *Explorer) isBranch(uid widget.TreeNodeID) bool {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+	return ex.dirs[uid]
+}
+
+func (ex *Explorer) createNode(branch bool) fyne.CanvasObject {
+	label := widget.NewLabel("")
+	label.Truncation = fyne.TextTruncateEllipsis
+	return container.NewBorder(nil, nil, widget.NewIcon(nil), nil, label)
+}
+
+func (ex *Explorer) updateNode(uid widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+	row := obj.(*fyne.Container)
+	row.Objects[0].(*widget.Label).SetText(filepath.Base(uid))
+	if branch {
+		row.Objects[1].(*widget.Icon).SetResource(theme.FolderIcon())
+	} else {
+		row.Objects[1].(*widget.Icon).SetResource(theme.FileIcon())
+	}
+}
+
+// Open files when they are clicked.
+func (ex *Explorer) nodeSelected(uid widget.TreeNodeID) {
+	ex.selected = uid
+	if ex.isBranch(uid) {
+		return
+	}
+	if _, err := ex.ui.OpenPath(uid); err != nil {
+		dialog.ShowError(err, ex.ui.Window)
+	}
+}
+
+// Forget cached listings touched by filesystem events and redraw once they settle.
+func (ex *Explorer) watch(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			ex.invalidate(filepath.Dir(event.Name))
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				ex.invalidate(event.Name)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fyne.LogError("Explorer watcher failed", err)
+		}
+	}
+}
+
+// Drop the listing of dir and schedule a redraw.
+func (ex *Explorer) invalidate(dir string) {
+	ex.lock.Lock()
+	defer ex.lock.Unlock()
+
+	delete(ex.children, dir)
+	if ex.refresh != nil {
+		ex.refresh.Stop()
+	}
+	ex.refresh = time.AfterFunc(explorerRefresh, func() { fyne.Do(ex.Tree.Refresh) })
+}
+
+// The directory new entries go into: the selected folder, or the selected file's folder.
+func (ex *Explorer) targetDir() string {
+	if ex.selected == "" {
+		return ex.Root
+	}
+	if ex.isBranch(ex.selected) {
+		return ex.selected
+	}
+	return filepath.Dir(ex.selected)
+}
+
+// Ask for a name and create an empty file or folder.
+func (ex *Explorer) createEntry(folder bool) {
+	if ex.Root == "" {
+		ex.ui.ChooseFolder()
+		return
+	}
+
+	dir := ex.targetDir()
+	title := "New File"
+	if folder {
+		title = "New Folder"
+	}
+	ex.askName(title, "", func(name string) {
+		path := filepath.Join(dir, name)
+		var err error
+		if folder {
+			err = os.Mkdir(path, 0755)
+		} else {
+			err = handling.CreateFile(path)
+		}
+		if err != nil {
+			dialog.ShowError(err, ex.ui.Window)
+			return
+		}
+
+		ex.invalidate(dir)
+		ex.Tree.OpenBranch(dir)
+		if !folder {
+			if _, err := ex.ui.OpenPath(path); err != nil {
+				dialog.ShowError(err, ex.ui.Window)
+			}
+		}
+	})
+}
+
+// Ask for a new name for the selected entry.
+func (ex *Explorer) renameSelected() {
+	old := ex.selected
+	if old == "" {
+		return
+	}
+	ex.askName("Rename", filepath.Base(old), func(name string) {
+		ex.movePath(old, filepath.Join(filepath.Dir(old), name))
+	})
+}
+
+// Ask for a folder to move the selected entry into.
+func (ex *Explorer) moveSelected() {
+	old := ex.selected
+	if old == "" {
+		return
+	}
+
+	picker := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, ex.ui.Window)
+			return
+		}
+		if dir == nil {
+			return
+		}
+		ex.movePath(old, filepath.Join(dir.Path(), filepath.Base(old)))
+	}, ex.ui.Window)
+	if root, err := storage.ListerForURI(storage.NewFileURI(ex.Root)); err == nil {
+		picker.SetLocation(root)
+	}
+	picker.Show()
+}
+
+// Rename or move a file or folder, keeping open documents pointed at it.
+func (ex *Explorer) movePath(old, path string) {
+	if old == path {
+		return
+	}
+	if _, err := os.Stat(path); err == nil {
+		dialog.ShowError(fmt.Errorf("%s already exists", path), ex.ui.Window)
+		return
+	}
+	if err := os.Rename(old, path); err != nil {
+		dialog.ShowError(err, ex.ui.Window)
+		return
+	}
+
+	ex.ui.pathMoved(old, path)
+	ex.selected = ""
+	ex.Tree.UnselectAll()
+	ex.invalidate(filepath.Dir(old))
+	ex.invalidate(filepath.Dir(path))
+}
+
+// Confirm and delete the selected entry.
+func (ex *Explorer) deleteSelected() {
+	path := ex.selected
+	if path == "" {
+		return
+	}
+
+	message := fmt.Sprintf("Delete %s?", filepath.Base(path))
+	if ex.isBranch(path) {
+		message = fmt.Sprintf("Delete the folder %s and everything in it?", filepath.Base(path))
+	}
+	dialog.ShowConfirm("Delete", message, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := os.RemoveAll(path); err != nil {
+			dialog.ShowError(err, ex.ui.Window)
+			return
+		}
+
+		// Open documents no longer match anything on disk.
+		for _, doc := range ex.ui.Documents {
+			if doc.URI != nil && isWithin(doc.URI.Path(), path) {
+				ex.ui.setDirty(doc, true)
+			}
+		}
+		ex.selected = ""
+		ex.Tree.UnselectAll()
+		ex.invalidate(filepath.Dir(path))
+	}, ex.ui.Window)
+}
+
+// Ask for a file name.
+func (ex *Explorer) askName(title, initial string, onName func(string)) {
+	entry := widget.NewEntry()
+	entry.SetText(initial)
+	entry.Validator = func(name string) error {
+		if name == "" || name == "." || name == ".." || strings.ContainsRune(name, filepath.Separator) {
+			return fmt.Errorf("enter a file name")
+		}
+		return nil
+	}
+
+	items := []*widget.FormItem{widget.NewFormItem("Name", entry)}
+	dialog.ShowForm(title, "OK", "Cancel", items, func(ok bool) {
+		if ok {
+			onName(entry.Text)
+		}
+	}, ex.ui.Window)
+}
+
+// Report whether path is root or inside it.
+func isWithin(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
+
+// OpenFolder shows a folder in the explorer and makes it the Find in Folder default.
+func (ui *UI) OpenFolder(folder string) {
+	if err := ui.Explorer.SetRoot(folder); err != nil {
+		dialog.ShowError(err, ui.Window)
+		return
+	}
+	if ui.FolderSearch.Folder == "" {
+		ui.FolderSearch.SetFolder(folder)
+	}
+	if !ui.ExplorerVisible {
+		ui.toggleExplorer()
+	}
+}
+
+// ChooseFolder asks for a project folder to open.
+func (ui *UI) ChooseFolder() {
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, ui.Window)
+			return
+		}
+		if dir != nil {
+			ui.OpenFolder(dir.Path())
+		}
+	}, ui.Window)
+}
+
+// Show or hide the explorer.
+func (ui *UI) toggleExplorer() {
+	ui.ExplorerVisible = !ui.ExplorerVisible
+	ui.UpdateLayout()
+}
+
+// Point documents inside a moved file or folder at the new location.
+func (ui *UI) pathMoved(old, path string) {
+	for _, doc := range ui.Documents {
+		if doc.URI == nil || !isWithin(doc.URI.Path(), old) {
+			continue
+		}
+		doc.URI = storage.NewFileURI(path + strings.TrimPrefix(doc.URI.Path(), old))
+		ui.highlight(doc)
+		ui.setDirty(doc, doc.Dirty)
+	}
+	ui.updateTitle()
+}
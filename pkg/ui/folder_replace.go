@@ -0,0 +1,234 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// replaceHit is one planned replacement, shown as a before/after line pair.
+type replaceHit struct {
+	match       handling.LineMatch
+	replacement string
+	before      string
+	after       string
+	apply       bool
+}
+
+// replaceFile holds the planned replacements for one file, along with the
+// content they were planned against.
+type replaceFile struct {
+	path    string
+	content string
+	hits    []*replaceHit
+}
+
+// previewRow is a row of the preview list: a file header when hit is nil.
+type previewRow struct {
+	file *replaceFile
+	hit  *replaceHit
+}
+
+// Plan the replacement of every search result and ask which to apply.
+func (fs *FolderSearch) previewReplace() {
+	fs.lock.Lock()
+	results := append([]handling.FileResult(nil), fs.results...)
+	matcher, searching := fs.matcher, fs.cancel != nil
+	fs.lock.Unlock()
+
+	if searching {
+		dialog.ShowInformation("Replace in Folder", "Wait for the search to finish first.", fs.ui.Window)
+		return
+	}
+	if len(results) == 0 || matcher == nil {
+		dialog.ShowInformation("Replace in Folder", "Search the folder first.", fs.ui.Window)
+		return
+	}
+
+	// Match the files again as they are now, in case they changed since the search.
+	var files []*replaceFile
+	for _, result := range results {
+		data, err := os.ReadFile(result.Path)
+		if err != nil {
+			fyne.LogError("Failed to read "+result.Path, err)
+			continue
+		}
+		if file := planFile(result.Path, string(data), matcher, fs.ReplaceEntry.Text); len(file.hits) > 0 {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		dialog.ShowInformation("Replace in Folder", "The files no longer match; search again.", fs.ui.Window)
+		return
+	}
+
+	var rows []previewRow
+	for _, file := range files {
+		rows = append(rows, previewRow{file: file})
+		for _, hit := range file.hits {
+			rows = append(rows, previewRow{file: file, hit: hit})
+		}
+	}
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int { return len(rows) },
+		func() fyne.CanvasObject {
+			before := widget.NewLabel("")
+			before.Importance = widget.DangerImportance
+			before.Truncation = fyne.TextTruncateEllipsis
+			after := widget.NewLabel("")
+			after.Importance = widget.SuccessImportance
+			after.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, widget.NewCheck("", nil), nil, container.NewVBox(before, after))
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			row := rows[id]
+			border := obj.(*fyne.Container)
+			lines := border.Objects[0].(*fyne.Container)
+			check := border.Objects[1].(*widget.Check)
+			before, after := lines.Objects[0].(*widget.Label), lines.Objects[1].(*widget.Label)
+
+			check.OnChanged = nil
+			if row.hit == nil {
+				// File header: the check toggles every hit in the file.
+				name, err := filepath.Rel(fs.Folder, row.file.path)
+				if err != nil {
+					name = row.file.path
+				}
+				check.SetChecked(row.file.applied() == len(row.file.hits))
+				before.Importance = widget.HighImportance
+				before.SetText(fmt.Sprintf("%s (%d of %d)", name, row.file.applied(), len(row.file.hits)))
+				after.Hide()
+				check.OnChanged = func(on bool) {
+					for _, hit := range row.file.hits {
+						hit.apply = on
+					}
+					list.Refresh()
+				}
+				return
+			}
+
+			check.SetChecked(row.hit.apply)
+			before.Importance = widget.DangerImportance
+			before.SetText(fmt.Sprintf("- %d: %s", row.hit.match.Line, row.hit.before))
+			after.SetText(fmt.Sprintf("+ %d: %s", row.hit.match.Line, row.hit.after))
+			after.Show()
+			check.OnChanged = func(on bool) {
+				row.hit.apply = on
+				list.Refresh()
+			}
+		},
+	)
+
+	preview := dialog.NewCustomConfirm("Replace in Folder", "Apply", "Cancel", list, func(ok bool) {
+		if ok {
+			fs.applyReplace(files)
+		}
+	}, fs.ui.Window)
+	size := fs.ui.Window.Canvas().Size()
+	preview.Resize(fyne.NewSize(size.Width*0.8, size.Height*0.8))
+	preview.Show()
+}
+
+// Plan the replacements for every match in one file's content.
+func planFile(path, content string, matcher *handling.Matcher, replace string) *replaceFile {
+	file := &replaceFile{path: path, content: content}
+	for _, match := range handling.FindLines(content, matcher) {
+		replacement := matcher.Expand(content, match.Match, replace)
+
+		lineStart := strings.LastIndex(content[:match.Start], "\n") + 1
+		lineEnd := strings.IndexByte(content[match.End:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(content)
+		} else {
+			lineEnd += match.End
+		}
+
+		file.hits = append(file.hits, &replaceHit{
+			match:       match,
+			replacement: replacement,
+			before:      strings.TrimSpace(content[lineStart:lineEnd]),
+			after:       strings.TrimSpace(content[lineStart:match.Start] + replacement + content[match.End:lineEnd]),
+			apply:       true,
+		})
+	}
+	return file
+}
+
+// Count the hits ticked for replacement.
+func (file *replaceFile) applied() int {
+	count := 0
+	for _, hit := range file.hits {
+		if hit.apply {
+			count++
+		}
+	}
+	return count
+}
+
+// Write the ticked replacements, all files together, and report what changed.
+func (fs *FolderSearch) applyReplace(files []*replaceFile) {
+	contents := map[string]string{}
+	open := map[string]*Document{}
+	var skipped []string
+	replaced := 0
+
+	for _, file := range files {
+		var matches []handling.Match
+		var replacements []string
+		for _, hit := range file.hits {
+			if hit.apply {
+				matches = append(matches, hit.match.Match)
+				replacements = append(replacements, hit.replacement)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		// Refuse to write over changes made since the preview was built.
+		current, err := os.ReadFile(file.path)
+		if err != nil || string(current) != file.content {
+			dialog.ShowError(fmt.Errorf("%s changed since the preview was made; search again", file.path), fs.ui.Window)
+			return
+		}
+		doc := fs.ui.findDocument(storage.NewFileURI(file.path))
+		if doc != nil && doc.Dirty {
+			skipped = append(skipped, filepath.Base(file.path))
+			continue
+		}
+		if doc != nil {
+			open[file.path] = doc
+		}
+
+		contents[file.path] = handling.ReplaceMatches(file.content, matches, replacements)
+		replaced += len(matches)
+	}
+
+	if err := handling.WriteFiles(contents); err != nil {
+		dialog.ShowError(err, fs.ui.Window)
+		return
+	}
+
+	// Keep open tabs in step with the files, as an undoable change.
+	for path, doc := range open {
+		doc.Editor.SetText(contents[path])
+		fs.ui.setDirty(doc, false)
+	}
+
+	message := fmt.Sprintf("Replaced %d matches in %d files.", replaced, len(contents))
+	if len(skipped) > 0 {
+		message += fmt.Sprintf("\nSkipped files with unsaved changes: %s", strings.Join(skipped, ", "))
+	}
+	dialog.ShowInformation("Replace in Folder", message, fs.ui.Window)
+	fs.Start()
+}
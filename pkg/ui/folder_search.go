@@ -0,0 +1,319 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// folderSearchRefresh limits how often streamed results redraw the tree.
+const folderSearchRefresh = 150 * time.Millisecond
+
+// FolderSearch is the "Find in Folder" panel, searching every text file below a folder.
+type FolderSearch struct {
+	ui *UI
+
+	// Folder is the directory being searched.
+	Folder string
+
+	FolderLabel        *widget.Label
+	TermEntry          *widget.Entry
+	ReplaceEntry       *widget.Entry
+	CaseSensitiveCheck *widget.Check
+	WholeWordCheck     *widget.Check
+	RegexpCheck        *widget.Check
+	Status             *widget.Label
+	Results            *widget.Tree
+
+	panel fyne.CanvasObject
+
+	lock        sync.Mutex
+	results     []handling.FileResult
+	matcher     *handling.Matcher
+	cancel      context.CancelFunc
+	generation  int
+	lastRefresh time.Time
+}
+
+// NewFolderSearch creates the panel's widgets.
+func NewFolderSearch(ui *UI) *FolderSearch {
+	fs := &FolderSearch{
+		ui:                 ui,
+		FolderLabel:        widget.NewLabel("No folder selected"),
+		TermEntry:          widget.NewEntry(),
+		ReplaceEntry:       widget.NewEntry(),
+		CaseSensitiveCheck: widget.NewCheck("Match case", nil),
+		WholeWordCheck:     widget.NewCheck("Whole word", nil),
+		RegexpCheck:        widget.NewCheck("Regular expression", nil),
+		Status:             widget.NewLabel(""),
+	}
+	fs.CaseSensitiveCheck.SetChecked(true)
+	fs.TermEntry.SetPlaceHolder("Find in folder")
+	fs.TermEntry.OnSubmitted = func(string) { fs.Start() }
+	fs.ReplaceEntry.SetPlaceHolder("Replace with")
+	fs.FolderLabel.Truncation = fyne.TextTruncateEllipsis
+
+	fs.Results = widget.NewTree(fs.childUIDs, fs.isBranch, fs.createNode, fs.updateNode)
+	fs.Results.OnSelected = fs.openResult
+
+	fs.panel = container.NewBorder(
+		container.NewVBox(
+			container.NewBorder(nil, nil,
+				widget.NewButton("📁 Folder", func() { fs.chooseFolder() }),
+				widget.NewButton("❌ Close", func() { ui.toggleFolderSearch() }),
+				fs.FolderLabel,
+			),
+			container.NewBorder(nil, nil, nil,
+				container.NewHBox(
+					widget.NewButton("Search", func() { fs.Start() }),
+					widget.NewButton("Cancel", func() { fs.Cancel() }),
+				),
+				fs.TermEntry,
+			),
+			container.NewBorder(nil, nil, nil,
+				widget.NewButton("Replace…", func() { fs.previewReplace() }),
+				fs.ReplaceEntry,
+			),
+			container.NewHBox(fs.CaseSensitiveCheck, fs.WholeWordCheck, fs.RegexpCheck, fs.Status),
+		),
+		nil, nil, nil,
+		fs.Results,
+	)
+	return fs
+}
+
+// Panel returns the panel's content.
+func (fs *FolderSearch) Panel() fyne.CanvasObject {
+	return fs.panel
+}
+
+// Start searches the folder in the background, replacing any search still running.
+func (fs *FolderSearch) Start() {
+	if fs.Folder == "" {
+		fs.chooseFolder()
+		return
+	}
+	if fs.TermEntry.Text == "" {
+		return
+	}
+
+	matcher, err := handling.NewMatcher(fs.TermEntry.Text, handling.SearchOptions{
+		CaseSensitive: fs.CaseSensitiveCheck.Checked,
+		WholeWord:     fs.WholeWordCheck.Checked,
+		Regexp:        fs.RegexpCheck.Checked,
+	})
+	if err != nil {
+		fs.Status.SetText(err.Error())
+		return
+	}
+
+	fs.Cancel()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	fs.lock.Lock()
+	fs.results = nil
+	fs.matcher = matcher
+	fs.cancel = cancel
+	fs.generation++
+	generation := fs.generation
+	fs.lock.Unlock()
+	fs.Results.UnselectAll()
+	fs.Results.Refresh()
+	fs.Status.SetText("Searching…")
+
+	go func(folder string) {
+		err := handling.SearchFolder(ctx, folder, matcher, handling.DefaultMaxSearchFileSize, func(result handling.FileResult) {
+			fs.lock.Lock()
+			fs.results = append(fs.results, result)
+			refresh := time.Since(fs.lastRefresh) > folderSearchRefresh
+			if refresh {
+				fs.lastRefresh = time.Now()
+			}
+			fs.lock.Unlock()
+
+			if refresh {
+				fyne.Do(fs.Results.Refresh)
+			}
+		})
+
+		fs.lock.Lock()
+		files, count := len(fs.results), 0
+		for _, result := range fs.results {
+			count += len(result.Matches)
+		}
+		if fs.generation == generation {
+			fs.cancel = nil
+		}
+		fs.lock.Unlock()
+
+		fyne.Do(func() {
+			fs.Results.Refresh()
+
+			// A newer search, even one started since, owns the status line.
+			fs.lock.Lock()
+			current := fs.generation == generation
+			fs.lock.Unlock()
+			if !current {
+				return
+			}
+			switch {
+			case errors.Is(err, context.Canceled):
+				fs.Status.SetText(fmt.Sprintf("Cancelled: %d matches in %d files", count, files))
+			case err != nil:
+				fs.Status.SetText(err.Error())
+			case count == 0:
+				fs.Status.SetText("No results")
+			default:
+				fs.Status.SetText(fmt.Sprintf("%d matches in %d files", count, files))
+			}
+		})
+	}(fs.Folder)
+}
+
+// Cancel stops a running search.
+func (fs *FolderSearch) Cancel() {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if fs.cancel != nil {
+		fs.cancel()
+		fs.cancel = nil
+	}
+}
+
+// SetFolder changes the directory to search.
+func (fs *FolderSearch) SetFolder(folder string) {
+	fs.Folder = folder
+	fs.FolderLabel.SetText(folder)
+}
+
+// Ask for the directory to search.
+func (fs *FolderSearch) chooseFolder() {
+	dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil {
+			dialog.ShowError(err, fs.ui.Window)
+			return
+		}
+		if dir == nil {
+			return
+		}
+		fs.SetFolder(dir.Path())
+		fs.Start()
+	}, fs.ui.Window)
+}
+
+// Results are identified as "file" for a file and "file/match" for a match, by index.
+func (fs *FolderSearch) childUIDs(uid widget.TreeNodeID) []widget.TreeNodeID {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	var ids []widget.TreeNodeID
+	if uid == "" {
+		for i := range fs.results {
+			ids = append(ids, strconv.Itoa(i))
+		}
+		return ids
+	}
+
+	file, _, _ := fs.parseUID(uid)
+	if file < len(fs.results) {
+		for j := range fs.results[file].Matches {
+			ids = append(ids, fmt.Sprintf("%d/%d", file, j))
+		}
+	}
+	return ids
+}
+
+func (fs *FolderSearch) isBranch(uid widget.TreeNodeID) bool {
+	return uid == "" || !strings.Contains(uid, "/")
+}
+
+func (fs *FolderSearch) createNode(bool) fyne.CanvasObject {
+	label := widget.NewLabel("")
+	label.Truncation = fyne.TextTruncateEllipsis
+	return label
+}
+
+func (fs *FolderSearch) updateNode(uid widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	label := obj.(*widget.Label)
+	file, match, isMatch := fs.parseUID(uid)
+	if file >= len(fs.results) {
+		label.SetText("")
+		return
+	}
+
+	result := fs.results[file]
+	if !isMatch {
+		name, err := filepath.Rel(fs.Folder, result.Path)
+		if err != nil {
+			name = result.Path
+		}
+		label.SetText(fmt.Sprintf("%s (%d)", name, len(result.Matches)))
+		return
+	}
+	if match < len(result.Matches) {
+		m := result.Matches[match]
+		label.SetText(fmt.Sprintf("%d: %s", m.Line, strings.TrimSpace(m.Preview)))
+	}
+}
+
+// Open the file of a selected match with the match selected.
+func (fs *FolderSearch) openResult(uid widget.TreeNodeID) {
+	fs.lock.Lock()
+	file, match, isMatch := fs.parseUID(uid)
+	if !isMatch || file >= len(fs.results) || match >= len(fs.results[file].Matches) {
+		fs.lock.Unlock()
+		return
+	}
+	path := fs.results[file].Path
+	m := fs.results[file].Matches[match]
+	fs.lock.Unlock()
+
+	doc, err := fs.ui.OpenPath(path)
+	if err != nil {
+		dialog.ShowError(err, fs.ui.Window)
+		return
+	}
+	start := doc.Editor.Offset(m.Line-1, m.Column)
+	end := start + m.End - m.Start
+	if end > doc.Editor.Buffer.Len() {
+		end = start
+	}
+	doc.Editor.Select(start, end)
+}
+
+// Split a node ID into its file and match indices.
+func (fs *FolderSearch) parseUID(uid widget.TreeNodeID) (file, match int, isMatch bool) {
+	fileID, matchID, isMatch := strings.Cut(uid, "/")
+	file, _ = strconv.Atoi(fileID)
+	if isMatch {
+		match, _ = strconv.Atoi(matchID)
+	}
+	return file, match, isMatch
+}
+
+// Show or hide the Find in Folder panel.
+func (ui *UI) toggleFolderSearch() {
+	ui.FolderSearchVisible = !ui.FolderSearchVisible
+	if !ui.FolderSearchVisible {
+		ui.FolderSearch.Cancel()
+	}
+	ui.UpdateLayout()
+	if ui.FolderSearchVisible {
+		ui.Window.Canvas().Focus(ui.FolderSearch.TermEntry)
+	}
+}
@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// Find the repository around a document's file and what the file was at the
+// last commit, then mark its changes. Untitled and large documents are left out.
+// The repository is read in the background, as that can take a while.
+func (ui *UI) refreshGit(doc *Document) {
+	if doc.URI == nil || doc.Large {
+		ui.setGit(doc, nil, "", false)
+		return
+	}
+	uri := doc.URI
+	go func() {
+		var head string
+		var tracked bool
+		repo, err := handling.OpenRepository(uri.Path())
+		if err == nil {
+			if head, tracked, err = repo.HeadText(uri.Path()); err != nil {
+				fyne.LogError("Failed to read "+uri.Name()+" from the last commit", err)
+			}
+		}
+		fyne.Do(func() {
+			// The document may have been saved elsewhere meanwhile.
+			if doc.URI == uri && !doc.Large {
+				ui.setGit(doc, repo, head, tracked)
+			}
+		})
+	}()
+}
+
+// Record a document's repository and its file's text at the last commit, and mark its changes.
+func (ui *UI) setGit(doc *Document, repo *handling.Repository, head string, tracked bool) {
+	doc.git, doc.gitHead, doc.gitTracked = repo, head, tracked
+	ui.markGitChanges(doc)
+	if doc == ui.ActiveDocument() {
+		ui.showGit(doc)
+	}
+}
+
+// Mark the changes since the last commit again once typing settles, waiting longer for large documents.
+func (ui *UI) scheduleGitChanges(doc *Document) {
+	if doc.git == nil {
+		return
+	}
+	if doc.gitTimer != nil {
+		doc.gitTimer.Stop()
+	}
+	if doc.Editor.Buffer.Len() < largeSearchText {
+		ui.markGitChanges(doc)
+		return
+	}
+	doc.gitTimer = time.AfterFunc(searchDelay, func() { fyne.Do(func() { ui.markGitChanges(doc) }) })
+}
+
+// Mark the lines added, changed and removed since the last commit in the gutter.
+func (ui *UI) markGitChanges(doc *Document) {
+	doc.gitHunks = nil
+	var marks []handling.Mark
+	if doc.gitTracked {
+		doc.gitHunks = handling.DiffLines(handling.SplitLines(doc.gitHead), handling.SplitLines(doc.Editor.Text()))
+		added, changed, removed := themeColor(theme.ColorNameSuccess), themeColor(theme.ColorNameWarning), themeColor(theme.ColorNameError)
+		for _, h := range doc.gitHunks {
+			switch {
+			case h.LeftStart == h.LeftEnd:
+				marks = append(marks, lineMark(doc.Editor.Buffer, h.RightStart, h.RightEnd, added))
+			case h.RightStart == h.RightEnd:
+				marks = append(marks, lineMark(doc.Editor.Buffer, h.RightStart, h.RightEnd, removed))
+			default:
+				marks = append(marks, lineMark(doc.Editor.Buffer, h.RightStart, h.RightEnd, changed))
+			}
+		}
+	}
+	for _, view := range doc.Editor.Views() {
+		view.SetGutterMarks(marks)
+	}
+}
+
+// Show the branch and the state of the active document's file in the status bar.
+func (ui *UI) showGit(doc *Document) {
+	if doc.git == nil {
+		ui.GitStatus.Hide()
+		return
+	}
+	// Working out the state reads the file and the index, so it is done in the background.
+	repo, path, name := doc.git, doc.URI.Path(), doc.Name()
+	go func() {
+		state, err := repo.State(path)
+		if err != nil {
+			fyne.LogError("Failed to read the git state of "+name, err)
+		}
+		branch := repo.Branch()
+		fyne.Do(func() {
+			// Another document may have been selected meanwhile.
+			if doc != ui.ActiveDocument() || doc.git != repo {
+				return
+			}
+			ui.GitLabel.SetText(fmt.Sprintf("Git: %s (%s)", branch, state))
+			ui.GitStatus.Show()
+		})
+	}()
+}
+
+// Put back the lines the change at the cursor replaced, as they were at the last commit.
+func (ui *UI) revertGitChange() {
+	doc, editor := ui.ActiveDocument(), ui.activeEditor()
+	if doc == nil || doc.git == nil || editor.ReadOnly {
+		return
+	}
+	h, ok := gitHunkAt(doc.gitHunks, editor.CursorRow)
+	if !ok {
+		dialog.ShowInformation("Revert Change", "There is no change since the last commit at the cursor.", ui.Window)
+		return
+	}
+	lines, head := handling.SplitLines(editor.Text()), handling.SplitLines(doc.gitHead)
+	editor.SetText(strings.Join(slices.Replace(lines, h.RightStart, h.RightEnd, head[h.LeftStart:h.LeftEnd]...), "\n"))
+}
+
+// Show who last changed the line at the cursor, and in which commit.
+func (ui *UI) showGitBlame() {
+	doc, editor := ui.ActiveDocument(), ui.activeEditor()
+	if doc == nil || doc.git == nil {
+		return
+	}
+	row := editor.CursorRow
+	if h, ok := gitHunkAt(doc.gitHunks, row); ok && h.RightStart != h.RightEnd || !doc.gitTracked {
+		dialog.ShowInformation("Blame", fmt.Sprintf("Line %d is not committed yet.", row+1), ui.Window)
+		return
+	}
+
+	// Blaming walks the history, which can take a while.
+	repo, path := doc.git, doc.URI.Path()
+	headRow := int(handling.MapRow(doc.gitHunks, float32(row), true))
+	go func() {
+		blame, err := repo.Blame(path, headRow)
+		fyne.Do(func() {
+			if err != nil {
+				dialog.ShowError(err, ui.Window)
+				return
+			}
+			dialog.ShowInformation("Blame", fmt.Sprintf("Line %d: %s by %s on %s\n\n%s",
+				row+1, blame.Hash, blame.Author, blame.Date.Format("2006-01-02"), blame.Summary), ui.Window)
+		})
+	}()
+}
+
+// The hunk covering a row, or the lines removed just above it.
+func gitHunkAt(hunks []handling.Hunk, row int) (handling.Hunk, bool) {
+	for _, h := range hunks {
+		if row >= h.RightStart && row < h.RightEnd || h.RightStart == h.RightEnd && row == h.RightStart {
+			return h, true
+		}
+	}
+	return handling.Hunk{}, false
+}
+
+// A color of the current theme.
+func themeColor(name fyne.ThemeColorName) color.Color {
+	settings := fyne.CurrentApp().Settings()
+	return settings.Theme().Color(name, settings.ThemeVariant())
+}
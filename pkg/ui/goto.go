@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// ShowGoToLine asks for a line, line and column, or number of lines to move,
+// and moves the active editor's cursor there.
+func (ui *UI) ShowGoToLine() {
+	editor := ui.activeEditor()
+	if editor == nil {
+		return
+	}
+
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("line, line:column or +/-lines")
+	entry.Validator = func(text string) error {
+		_, _, err := handling.ParseGoTo(text, editor.CursorRow)
+		return err
+	}
+
+	form := dialog.NewForm("Go To Line", "Go", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Line", entry),
+	}, func(ok bool) {
+		if !ok {
+			ui.Window.Canvas().Focus(editor)
+			return
+		}
+		row, col, err := handling.ParseGoTo(entry.Text, editor.CursorRow)
+		if err != nil {
+			return
+		}
+		editor.GoTo(row, col)
+		ui.Window.Canvas().Focus(editor)
+	}, ui.Window)
+	entry.OnSubmitted = func(string) { form.Submit() }
+	form.Show()
+	ui.Window.Canvas().Focus(entry)
+}
@@ -0,0 +1,46 @@
+package ui
+
+import "github.com/Leda-Editor/Leda-Text-Editor/pkg/syntax"
+
+// Pick the document's language from its name or "#!" line and bring its tokens up to date.
+// Large files are left plain.
+func (ui *UI) highlight(doc *Document) {
+	if doc.Large {
+		ui.setHighlighter(doc, nil)
+		if doc == ui.ActiveDocument() {
+			ui.showLanguage(doc)
+		}
+		return
+	}
+
+	// The editor passes its edits on to the highlighter, so the whole text is
+	// only needed for a new language or a newly loaded buffer.
+	grammar := syntax.Detect(doc.Name(), doc.Editor.Buffer.Line(0))
+	h := doc.Editor.Highlighter
+	if h == nil || h.Grammar() != grammar || h.Len() != doc.Editor.Buffer.Lines() {
+		h = syntax.NewHighlighter(grammar)
+		h.SetText(doc.Editor.Text())
+		ui.setHighlighter(doc, h)
+	}
+
+	if doc == ui.ActiveDocument() {
+		ui.showLanguage(doc)
+	}
+}
+
+// Color every editor of the document with h.
+func (ui *UI) setHighlighter(doc *Document, h *syntax.Highlighter) {
+	for _, view := range doc.Editor.Views() {
+		view.Highlighter = h
+		view.Refresh()
+	}
+}
+
+// Show the active document's language in the status bar.
+func (ui *UI) showLanguage(doc *Document) {
+	if doc.Editor.Highlighter == nil || doc.Editor.Highlighter.Grammar() == nil {
+		ui.LanguageLabel.SetText("Plain Text")
+		return
+	}
+	ui.LanguageLabel.SetText(doc.Editor.Highlighter.Grammar().Name)
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// Serve handles requests from other Leda processes until the listener is closed.
+func (ui *UI) Serve(listener net.Listener) {
+	ui.listener = listener
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if err != nil {
+				fyne.LogError("Failed to accept a connection", err)
+				continue
+			}
+			go ui.serveConn(conn)
+		}
+	}()
+}
+
+// Stop listening for other Leda processes.
+func (ui *UI) stopServing() {
+	if ui.listener != nil {
+		ui.listener.Close()
+		ui.listener = nil
+	}
+}
+
+// Answer the requests of one connection, remembering the files it waits for.
+// Each request is handed over to the UI thread, while waiting happens here.
+func (ui *UI) serveConn(conn net.Conn) {
+	var waitDocs []*Document
+	handling.Serve(conn, func(request handling.Request) error {
+		var err error
+		switch request.Command {
+		case handling.CommandOpen:
+			location := handling.Location{Path: request.Path, Line: request.Line, Column: request.Column}
+			fyne.DoAndWait(func() {
+				var doc *Document
+				doc, err = ui.openLocation(location, request.ReadOnly)
+				if doc != nil && request.Wait {
+					waitDocs = append(waitDocs, doc)
+				}
+			})
+		case handling.CommandNew:
+			fyne.DoAndWait(func() {
+				doc := ui.openText(request.Content)
+				if request.Wait {
+					waitDocs = append(waitDocs, doc)
+				}
+			})
+		case handling.CommandFocus:
+			fyne.DoAndWait(func() {
+				if doc := ui.ActiveDocument(); doc != nil {
+					ui.Window.Canvas().Focus(doc.Editor)
+				}
+				ui.Window.Show()
+				ui.Window.RequestFocus()
+			})
+		case handling.CommandWait:
+			closed := make(chan struct{})
+			fyne.DoAndWait(func() {
+				// Files closed already need no waiting for.
+				waitDocs = slices.DeleteFunc(waitDocs, func(doc *Document) bool {
+					return !slices.Contains(ui.Documents, doc)
+				})
+				if len(waitDocs) == 0 {
+					close(closed)
+					return
+				}
+				ui.waitFor(waitDocs, func() { close(closed) })
+			})
+			waitDocs = nil
+			<-closed
+		default:
+			err = fmt.Errorf("unknown command %q", request.Command)
+		}
+		return err
+	})
+}
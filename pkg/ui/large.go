@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// Preference key for opening large files read-only.
+const large_files_read_only = "large_files_read_only"
+
+// Open a large file in large-file mode, paging it in from disk as it is viewed.
+func (ui *UI) openLargeFile(path string) (*Document, error) {
+	buffer, err := handling.OpenFileBuffer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := ui.emptyDocument()
+	doc.URI = storage.NewFileURI(path)
+	doc.Large = true
+	doc.Editor.LoadBuffer(buffer)
+	doc.setReadOnly(ui.largeFilesReadOnly())
+	ui.highlight(doc)
+	ui.refreshGit(doc)
+	ui.setDirty(doc, false)
+	ui.documentSelected(doc)
+	return doc, nil
+}
+
+// Show in the status bar whether a document is in large-file mode.
+func (ui *UI) showLargeFile(doc *Document) {
+	if !doc.Large {
+		ui.LargeFileStatus.Hide()
+		return
+	}
+	if doc.Editor.ReadOnly {
+		ui.LargeFileLabel.SetText("Large File (read-only)")
+	} else {
+		ui.LargeFileLabel.SetText("Large File")
+	}
+	ui.LargeFileStatus.Show()
+}
+
+// Whether large files open read-only, which they do unless turned off.
+func (ui *UI) largeFilesReadOnly() bool {
+	return ui.App.Preferences().BoolWithFallback(large_files_read_only, true)
+}
+
+// Switch whether large files open read-only, updating the menu item's check mark.
+func (ui *UI) toggleLargeFilesReadOnly(item *fyne.MenuItem, menu *fyne.Menu) {
+	item.Checked = !ui.largeFilesReadOnly()
+	ui.App.Preferences().SetBool(large_files_read_only, item.Checked)
+	menu.Refresh()
+}
@@ -13,11 +13,19 @@ func (ui *UI) Layout() fyne.CanvasObject {
 		ui.CharacterLabel,
 		widget.NewLabel(" | "),
 		ui.LineLabel,
+		widget.NewLabel(" | "),
+		ui.LanguageLabel,
+		ui.LargeFileStatus,
+		ui.GitStatus,
 	)
 
-	sidebar := container.NewVBox(
+	sidebarControls := container.NewVBox(
 		widget.NewLabel("🔍 Search"),
 		ui.SearchTermEntry,
+		ui.SearchError,
+		ui.CaseSensitiveCheck,
+		ui.WholeWordCheck,
+		ui.RegexpCheck,
 		widget.NewButton("Search", func() { ui.performSearch() }),
 		ui.SearchResults,
 		widget.NewSeparator(),
@@ -30,32 +38,37 @@ func (ui *UI) Layout() fyne.CanvasObject {
 		widget.NewButton("⬇️ Next", func() { ui.nextMatch() }),
 		widget.NewButton("❌ Close", func() { ui.toggleSidebar() }),
 	)
+	sidebar := container.NewBorder(sidebarControls, nil, nil, nil, ui.MatchList)
 
-	var content fyne.CanvasObject
-	if ui.SidebarVisible {
-		split := container.NewHSplit(
-			sidebar,
-			container.NewScroll(ui.Editor),
-		)
-		split.SetOffset(0.2)
+	// The explorer and search sidebar share the left pane when both are shown.
+	var left fyne.CanvasObject
+	switch {
+	case ui.ExplorerVisible && ui.SidebarVisible:
+		split := container.NewVSplit(ui.Explorer.Panel(), sidebar)
+		split.SetOffset(0.4)
+		left = split
+	case ui.ExplorerVisible:
+		left = ui.Explorer.Panel()
+	case ui.SidebarVisible:
+		left = sidebar
+	}
 
-		if ui.ShowMarkdown {
-			content = container.NewHSplit(
-				split,
-				container.NewScroll(ui.Markdown),
-			)
-		} else {
-			content = split
-		}
-	} else {
-		if ui.ShowMarkdown {
-			content = container.NewHSplit(
-				container.NewScroll(ui.Editor),
-				container.NewScroll(ui.Markdown),
-			)
-		} else {
-			content = container.NewScroll(ui.Editor)
-		}
+	var content fyne.CanvasObject = ui.Tabs
+	if left != nil {
+		split := container.NewHSplit(left, ui.Tabs)
+		split.SetOffset(0.2)
+		content = split
+	}
+	if ui.ShowMarkdown {
+		content = container.NewHSplit(
+			content,
+			ui.MarkdownScroll,
+		)
+	}
+	if ui.FolderSearchVisible {
+		split := container.NewVSplit(content, ui.FolderSearch.Panel())
+		split.SetOffset(0.65)
+		content = split
 	}
 	return container.NewBorder(nil, statusBar, nil, nil, content)
 }
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// Preference key for how the gutter numbers lines.
+const line_numbers = "line_numbers"
+
+// Line numbering styles, as stored in the preferences and named in the menu.
+var lineNumberStyles = []struct {
+	key   string
+	label string
+	style handling.LineNumbers
+}{
+	{"absolute", "Absolute", handling.LineNumbersAbsolute},
+	{"relative", "Relative", handling.LineNumbersRelative},
+	{"hidden", "Hidden", handling.LineNumbersHidden},
+}
+
+// The line numbering style picked in the preferences, absolute by default.
+func (ui *UI) lineNumbers() handling.LineNumbers {
+	key := ui.App.Preferences().StringWithFallback(line_numbers, "absolute")
+	for _, s := range lineNumberStyles {
+		if s.key == key {
+			return s.style
+		}
+	}
+	return handling.LineNumbersAbsolute
+}
+
+// Create the View menu's Line Numbers item, with a choice of each style.
+func (ui *UI) lineNumbersMenuItem() *fyne.MenuItem {
+	item := fyne.NewMenuItem("Line Numbers", nil)
+	item.ChildMenu = fyne.NewMenu("")
+	for _, s := range lineNumberStyles {
+		choice := fyne.NewMenuItem(s.label, nil)
+		choice.Checked = s.style == ui.lineNumbers()
+		choice.Action = func() {
+			ui.App.Preferences().SetString(line_numbers, s.key)
+			for _, other := range item.ChildMenu.Items {
+				other.Checked = other == choice
+			}
+			item.ChildMenu.Refresh()
+			for _, doc := range ui.Documents {
+				for _, view := range doc.Editor.Views() {
+					view.SetLineNumbers(s.style)
+				}
+			}
+		}
+		item.ChildMenu.Items = append(item.ChildMenu.Items, choice)
+	}
+	return item
+}
@@ -4,29 +4,90 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
 	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
 )
 
 // Creates a functional menu bar.
 func (ui *UI) CreateMenuBar() *fyne.Container {
+	exitItem := fyne.NewMenuItem("Exit", func() { ui.Exit() })
+	exitItem.IsQuit = true
+
+	compareItem := fyne.NewMenuItem("Compare", nil)
+	compareItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("With Saved Version", func() { ui.compareWithSaved() }),
+		fyne.NewMenuItem("With Open Document…", func() { ui.compareWithDocument() }),
+		fyne.NewMenuItem("Two Files…", func() { ui.compareFiles() }),
+	)
+
+	largeReadOnlyItem := fyne.NewMenuItem("Open Large Files Read-Only", nil)
+	largeReadOnlyItem.Checked = ui.largeFilesReadOnly()
+
 	fileMenu := fyne.NewMenu("File",
-		fyne.NewMenuItem("Open", func() { handling.OpenFile(ui.Window, ui.Editor) }),
-		fyne.NewMenuItem("Save", func() { handling.SaveFile(ui.Window, ui.Editor) }),
-		fyne.NewMenuItem("Exit", func() { handling.ClearEditor(ui.Editor) }),
+		fyne.NewMenuItem("New", func() { ui.NewDocument() }),
+		fyne.NewMenuItem("Open", func() {
+			handling.OpenFile(ui.Window, func(uri fyne.URI, content string) { ui.OpenDocument(uri, content) }, func(uri fyne.URI) {
+				if _, err := ui.OpenPath(uri.Path()); err != nil {
+					dialog.ShowError(err, ui.Window)
+				}
+			})
+		}),
+		fyne.NewMenuItem("Open Folder…", func() { ui.ChooseFolder() }),
+		fyne.NewMenuItem("Save", func() { ui.SaveDocument(ui.ActiveDocument()) }),
+		fyne.NewMenuItem("Save As…", func() { ui.SaveDocumentAs(ui.ActiveDocument()) }),
+		compareItem,
+		fyne.NewMenuItem("Autosave Settings…", func() { ui.OpenAutosaveSettings() }),
+		largeReadOnlyItem,
+		fyne.NewMenuItem("Close Tab", func() { ui.CloseDocument(ui.ActiveDocument()) }),
+		exitItem,
 	)
 
+	largeReadOnlyItem.Action = func() { ui.toggleLargeFilesReadOnly(largeReadOnlyItem, fileMenu) }
+
+	previewSyncItem := fyne.NewMenuItem("Sync Preview Scrolling", nil)
+	previewSyncItem.Checked = ui.previewSync()
+
 	viewMenu := fyne.NewMenu("View",
 		fyne.NewMenuItem("Zoom Out", func() { ui.ZoomOut() }),
 		fyne.NewMenuItem("Zoom In", func() { ui.ZoomIn() }),
+		fyne.NewMenuItem("Show/Hide Explorer", func() { ui.toggleExplorer() }),
 		fyne.NewMenuItem("Show/Hide Markdown Preview", func() { ui.toggleMarkdownPreview() }),
+		previewSyncItem,
+		ui.lineNumbersMenuItem(),
+		fyne.NewMenuItem("Split Editor Right", func() { ui.splitEditor(false) }),
+		fyne.NewMenuItem("Split Editor Down", func() { ui.splitEditor(true) }),
+		fyne.NewMenuItem("Close Split", func() { ui.closeSplit() }),
 		fyne.NewMenuItem("Dark Mode On/Off", func() { ToggleDarkMode(ui.App, ui) }),
 		fyne.NewMenuItem("Set Custom Theme", func() {
 			OpenThemePickerModal(ui.App, ui.Window, ui)
 		}),
 	)
 
+	previewSyncItem.Action = func() { ui.togglePreviewSync(previewSyncItem, viewMenu) }
+
+	undoItem := fyne.NewMenuItem("Undo", func() { ui.activeEditor().Undo() })
+	ui.addShortcut(undoItem, &fyne.ShortcutUndo{})
+	redoItem := fyne.NewMenuItem("Redo", func() { ui.activeEditor().Redo() })
+	ui.addShortcut(redoItem, &desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift})
+	ui.Window.Canvas().AddShortcut(&fyne.ShortcutRedo{}, func(fyne.Shortcut) { redoItem.Action() })
+
+	findInFolderItem := fyne.NewMenuItem("Find in Folder…", func() { ui.toggleFolderSearch() })
+	ui.addShortcut(findInFolderItem, &desktop.CustomShortcut{KeyName: fyne.KeyF, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift})
+
+	goToLineItem := fyne.NewMenuItem("Go To Line…", func() { ui.ShowGoToLine() })
+	ui.addShortcut(goToLineItem, &desktop.CustomShortcut{KeyName: fyne.KeyG, Modifier: fyne.KeyModifierShortcutDefault})
+
+	nextOccurrenceItem := fyne.NewMenuItem("Add Next Occurrence", func() { ui.addNextOccurrence() })
+	ui.addShortcut(nextOccurrenceItem, &desktop.CustomShortcut{KeyName: fyne.KeyD, Modifier: fyne.KeyModifierShortcutDefault})
+
 	editMenu := fyne.NewMenu("Edit",
+		undoItem,
+		redoItem,
+		fyne.NewMenuItemSeparator(),
+		goToLineItem,
+		nextOccurrenceItem,
 		fyne.NewMenuItem("Find/Replace", func() { ui.toggleSidebar() }),
+		findInFolderItem,
 	)
 
 	helpMenu := fyne.NewMenu("Help",
@@ -35,8 +96,20 @@ func (ui *UI) CreateMenuBar() *fyne.Container {
 		}),
 	)
 
-	mainMenu := fyne.NewMainMenu(fileMenu, viewMenu, editMenu, helpMenu)
+	gitMenu := fyne.NewMenu("Git",
+		fyne.NewMenuItem("Revert Change", func() { ui.revertGitChange() }),
+		fyne.NewMenuItem("Show Blame for Line", func() { ui.showGitBlame() }),
+		fyne.NewMenuItem("Refresh Status", func() { ui.refreshGit(ui.ActiveDocument()) }),
+	)
+
+	mainMenu := fyne.NewMainMenu(fileMenu, viewMenu, editMenu, gitMenu, helpMenu)
 	ui.Window.SetMainMenu(mainMenu)
 
 	return container.NewVBox()
 }
+
+// Show a shortcut next to a menu item and trigger the item when it is typed outside the editor.
+func (ui *UI) addShortcut(item *fyne.MenuItem, shortcut fyne.Shortcut) {
+	item.Shortcut = shortcut
+	ui.Window.Canvas().AddShortcut(shortcut, func(fyne.Shortcut) { item.Action() })
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// Preference key for scrolling the Markdown preview along with the editor.
+const markdown_scroll_sync = "markdown_scroll_sync"
+
+// Whether the preview follows the editor and back, which it does unless turned off.
+func (ui *UI) previewSync() bool {
+	return ui.App.Preferences().BoolWithFallback(markdown_scroll_sync, true)
+}
+
+// Switch whether the preview follows the editor, updating the menu item's check mark.
+func (ui *UI) togglePreviewSync(item *fyne.MenuItem, menu *fyne.Menu) {
+	item.Checked = !ui.previewSync()
+	ui.App.Preferences().SetBool(markdown_scroll_sync, item.Checked)
+	menu.Refresh()
+	ui.syncPreview()
+}
+
+// Render each block of the source in a rich text of its own, so the preview
+// knows where every source row lands. Unchanged blocks are not parsed again.
+func (ui *UI) renderMarkdownBlocks(input string) {
+	blocks := handling.SplitMarkdown(input)
+	ui.markdownRows = ui.markdownRows[:0]
+	for i, block := range blocks {
+		if i < len(ui.markdownTexts) {
+			if ui.markdownTexts[i] != block.Text {
+				ui.Markdown.Objects[i].(*widget.RichText).ParseMarkdown(block.Text)
+				ui.markdownTexts[i] = block.Text
+			}
+		} else {
+			ui.Markdown.Objects = append(ui.Markdown.Objects, widget.NewRichTextFromMarkdown(block.Text))
+			ui.markdownTexts = append(ui.markdownTexts, block.Text)
+		}
+		ui.markdownRows = append(ui.markdownRows, block.Row)
+	}
+	ui.Markdown.Objects = ui.Markdown.Objects[:len(blocks)]
+	ui.markdownTexts = ui.markdownTexts[:len(blocks)]
+
+	// Lay the blocks out now, so syncPreview finds them where they will be drawn.
+	ui.Markdown.Resize(ui.Markdown.MinSize().Max(ui.MarkdownScroll.Size()))
+	ui.Markdown.Refresh()
+}
+
+// Scroll the preview to the block the cursor of the active editor is in, part
+// way through it as far as the cursor is through its rows.
+func (ui *UI) syncPreview() {
+	doc := ui.ActiveDocument()
+	if !ui.ShowMarkdown || !ui.previewSync() || doc == nil || doc.Large || len(ui.markdownRows) == 0 {
+		return
+	}
+	editor := ui.activeEditor()
+	row := editor.CursorRow
+	i := max(0, sort.SearchInts(ui.markdownRows, row+1)-1)
+	block := ui.Markdown.Objects[i]
+	y := block.Position().Y
+	start, end := ui.markdownRows[i], editor.Buffer.Lines()
+	if i+1 < len(ui.markdownRows) {
+		end = ui.markdownRows[i+1]
+	}
+	if end > start {
+		y += block.Size().Height * float32(row-start) / float32(end-start)
+	}
+
+	// Keep the cursor's place a third of the way down the preview.
+	size := ui.MarkdownScroll.Size()
+	y = max(0, min(y-size.Height/3, ui.Markdown.Size().Height-size.Height))
+	if y == ui.MarkdownScroll.Offset.Y {
+		return
+	}
+	ui.markdownSyncing = true
+	ui.MarkdownScroll.Offset.Y = y
+	ui.MarkdownScroll.Refresh()
+	ui.markdownSyncing = false
+}
+
+// Scroll the active editor to the source rows of the block a third of the way
+// down the preview, as the preview scrolls.
+func (ui *UI) syncEditor(offset fyne.Position) {
+	doc := ui.ActiveDocument()
+	if ui.markdownSyncing || !ui.previewSync() || doc == nil || doc.Large || len(ui.markdownRows) == 0 {
+		return
+	}
+	y := offset.Y + ui.MarkdownScroll.Size().Height/3
+	i := max(0, sort.Search(len(ui.Markdown.Objects), func(i int) bool {
+		return ui.Markdown.Objects[i].Position().Y > y
+	})-1)
+	block := ui.Markdown.Objects[i]
+	editor := ui.activeEditor()
+	start, end := ui.markdownRows[i], editor.Buffer.Lines()
+	if i+1 < len(ui.markdownRows) {
+		end = ui.markdownRows[i+1]
+	}
+	row := float32(start)
+	if height := block.Size().Height; height > 0 {
+		row += float32(end-start) * min(1, (y-block.Position().Y)/height)
+	}
+
+	// Put the row a third of the way down the editor too.
+	_, x := editor.ScrollPosition()
+	editor.ScrollTo(max(0, row-editor.VisibleRows()/3), x)
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// journalInterval is how often open buffers are written to the recovery journal.
+const journalInterval = 10 * time.Second
+
+// JournalDir returns where the crash recovery journal is kept.
+func (ui *UI) JournalDir() (fyne.URI, error) {
+	return storage.Child(ui.App.Storage().RootURI(), "journal")
+}
+
+// RecoverSession offers to restore the buffers of sessions that did not shut down cleanly,
+// then starts journaling this session. Sessions still running are left alone.
+func (ui *UI) RecoverSession() {
+	dir, err := ui.JournalDir()
+	if err != nil {
+		fyne.LogError("Failed to find recovery journal", err)
+		return
+	}
+
+	stale := handling.StaleSessions(dir)
+	var entries []handling.JournalEntry
+	for _, pid := range stale {
+		journal, err := handling.ReadJournal(dir, pid)
+		if err != nil {
+			fyne.LogError("Failed to read recovery journal", err)
+		}
+		entries = append(entries, journal...)
+	}
+	ui.startJournal(dir)
+
+	// The journals are only removed once answered for, in case of another crash.
+	discard := func() {
+		for _, pid := range stale {
+			handling.ClearJournal(dir, pid)
+		}
+	}
+	if len(entries) == 0 {
+		discard()
+		return
+	}
+	message := fmt.Sprintf("Leda did not shut down cleanly.\nRestore %d document(s) from the last session?", len(entries))
+	dialog.ShowConfirm("Restore Unsaved Work", message, func(restore bool) {
+		if restore {
+			ui.restoreJournal(entries)
+		}
+		discard()
+	}, ui.Window)
+}
+
+// Mark the session as running and snapshot open buffers until stopJournal is called.
+func (ui *UI) startJournal(dir fyne.URI) {
+	if err := handling.MarkRunning(dir); err != nil {
+		fyne.LogError("Failed to start recovery journal", err)
+		return
+	}
+
+	ui.journalDir = dir
+	ui.journalStop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(journalInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// Snapshot the buffers on the UI thread, and read and write them out here.
+				var docs []journalDoc
+				fyne.DoAndWait(func() { docs = ui.snapshot() })
+				entries := make([]handling.JournalEntry, len(docs))
+				for i, doc := range docs {
+					entries[i] = doc.entry
+					entries[i].Content = doc.buffer.String()
+				}
+				ui.journalLock.Lock()
+				select {
+				case <-stop:
+					// Stopped meanwhile, so the journal is already gone.
+				default:
+					if err := handling.WriteJournal(dir, entries); err != nil {
+						fyne.LogError("Failed to write recovery journal", err)
+					}
+				}
+				ui.journalLock.Unlock()
+			case <-stop:
+				return
+			}
+		}
+	}(ui.journalStop)
+}
+
+// Stop journaling and remove the journal, marking a clean shutdown.
+func (ui *UI) stopJournal() {
+	if ui.journalStop == nil {
+		return
+	}
+	ui.journalLock.Lock()
+	defer ui.journalLock.Unlock()
+	close(ui.journalStop)
+	ui.journalStop = nil
+	handling.ClearJournal(ui.journalDir, os.Getpid())
+}
+
+// journalDoc is a document as the journal records it, with its text yet to be read.
+type journalDoc struct {
+	entry  handling.JournalEntry
+	buffer *handling.Buffer
+}
+
+// Capture the state of every document with work to lose: the modified ones,
+// and untitled ones that are not empty. Files unchanged on disk need no copy.
+func (ui *UI) snapshot() []journalDoc {
+	var docs []journalDoc
+	for _, doc := range ui.Documents {
+		if !doc.Dirty && (doc.URI != nil || doc.Editor.Buffer.Len() == 0) {
+			continue
+		}
+		entry := handling.JournalEntry{
+			Title:        doc.Name(),
+			CursorRow:    doc.Editor.CursorRow,
+			CursorColumn: doc.Editor.CursorColumn,
+			Dirty:        doc.Dirty,
+		}
+		if doc.URI != nil {
+			entry.URI = doc.URI.String()
+		}
+		docs = append(docs, journalDoc{entry, doc.Editor.Buffer.Snapshot()})
+	}
+	return docs
+}
+
+// Reopen the documents recorded in a journal.
+func (ui *UI) restoreJournal(entries []handling.JournalEntry) {
+	for _, entry := range entries {
+		doc := ui.emptyDocument()
+		if entry.URI != "" {
+			uri, err := storage.ParseURI(entry.URI)
+			if err != nil {
+				fyne.LogError("Failed to restore location of "+entry.Title, err)
+			}
+			doc.URI = uri
+		}
+		if doc.URI == nil {
+			doc.Title = entry.Title
+		}
+
+		doc.Editor.Load(entry.Content)
+		doc.Editor.GoTo(entry.CursorRow, entry.CursorColumn)
+		ui.setDirty(doc, entry.Dirty)
+	}
+	ui.documentSelected(ui.ActiveDocument())
+}
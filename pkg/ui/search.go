@@ -3,159 +3,339 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
 )
 
+// largeSearchText is the document size above which incremental searches are debounced.
+const largeSearchText = 256 * 1024
+
+// searchDelay is how long to wait for typing to settle before searching a large document.
+const searchDelay = 300 * time.Millisecond
+
+// maxMatchMarks caps how many matches are marked in the editor, as every mark
+// is placed again each time the editor is drawn.
+const maxMatchMarks = 10000
+
+// FindEntry is the search term field, where Enter and Shift+Enter step through matches.
+type FindEntry struct {
+	widget.Entry
+	// OnNext & OnPrevious are called for Enter and Shift+Enter.
+	OnNext, OnPrevious func()
+
+	shift bool
+}
+
+// NewFindEntry creates a single-line search term field.
+func NewFindEntry() *FindEntry {
+	entry := &FindEntry{}
+	entry.Wrapping = fyne.TextWrap(fyne.TextTruncateClip)
+	entry.ExtendBaseWidget(entry)
+	return entry
+}
+
+// KeyDown tracks whether shift is held.
+func (e *FindEntry) KeyDown(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shift = true
+	}
+	e.Entry.KeyDown(key)
+}
+
+// KeyUp tracks whether shift is held.
+func (e *FindEntry) KeyUp(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		e.shift = false
+	}
+	e.Entry.KeyUp(key)
+}
+
+// TypedKey moves between matches on Enter and Shift+Enter.
+func (e *FindEntry) TypedKey(key *fyne.KeyEvent) {
+	if key.Name != fyne.KeyReturn && key.Name != fyne.KeyEnter {
+		e.Entry.TypedKey(key)
+		return
+	}
+
+	if e.shift && e.OnPrevious != nil {
+		e.OnPrevious()
+	} else if !e.shift && e.OnNext != nil {
+		e.OnNext()
+	}
+}
+
 // Perform search and highlight results.
 func (ui *UI) performSearch() {
-	term := ui.SearchTermEntry.Text
-	if term == "" {
-		ui.SearchResults.SetText("Results: 0")
-		ui.Matches = []int{}
-		ui.RenderMarkdown(ui.Editor.Text)
-		return
+	ui.search(true)
+}
+
+// Search again once typing settles, waiting longer for large documents.
+// When jump is set the match nearest the cursor gets selected.
+func (ui *UI) scheduleSearch(jump bool) {
+	if ui.searchTimer != nil {
+		ui.searchTimer.Stop()
 	}
 
-	if ui.OriginalText == "" {
-		ui.OriginalText = ui.Editor.Text
+	if ui.ActiveDocument().Editor.Buffer.Len() < largeSearchText {
+		ui.search(jump)
+		return
 	}
+	ui.searchTimer = time.AfterFunc(searchDelay, func() {
+		fyne.Do(func() { ui.search(jump) })
+	})
+}
 
-	ui.Editor.SetText(ui.OriginalText)
+// Find all matches in the active document.
+func (ui *UI) search(jump bool) {
+	doc := ui.ActiveDocument()
+	term := ui.SearchTermEntry.Text
 
-	ui.Matches = []int{}
-	text := ui.Editor.Text
+	// Continue from the current match so extending the term keeps it selected.
+	anchor := doc.Editor.CursorOffset()
+	if doc.CurrentMatchIdx >= 0 && doc.CurrentMatchIdx < len(doc.Matches) {
+		anchor = doc.Matches[doc.CurrentMatchIdx].Start
+	}
+
+	doc.Matches = []handling.Match{}
+	doc.CurrentMatchIdx = -1
 
 	// Find all occurrences.
-	start := 0
-	for {
-		index := strings.Index(text[start:], term)
-		if index == -1 {
+	matcher, ok := ui.matcher()
+	if ok && term != "" {
+		doc.Matches = matcher.FindAll(doc.Editor.Text())
+	}
+
+	ui.markMatches(doc)
+	ui.MatchList.UnselectAll()
+	ui.MatchList.Refresh()
+	ui.updateSearchResults(doc)
+
+	if !jump || len(doc.Matches) == 0 {
+		return
+	}
+
+	// Jump to the first match after the cursor, wrapping around to the top.
+	next := 0
+	for i, match := range doc.Matches {
+		if match.Start >= anchor {
+			next = i
 			break
 		}
-		index += start
-		ui.Matches = append(ui.Matches, index)
-		start = index + len(term)
 	}
+	ui.scrollToMatch(next)
+}
 
-	count := len(ui.Matches)
-	ui.SearchResults.SetText(fmt.Sprintf("Results: %d", count))
-
-	if count == 0 {
-		dialog.ShowInformation("Find", fmt.Sprintf("No occurrences of '%s' found.", term), ui.Window)
-	} else {
-		ui.CurrentMatchIdx = 0
-		ui.scrollToMatch(ui.CurrentMatchIdx)
+// Mark every match of a document in its editors.
+func (ui *UI) markMatches(doc *Document) {
+	fill := tint(theme.ColorNamePrimary, 0x40)
+	marks := make([]handling.Mark, min(len(doc.Matches), maxMatchMarks))
+	for i := range marks {
+		marks[i] = handling.Mark{Start: doc.Matches[i].Start, End: doc.Matches[i].End, Color: fill}
+	}
+	doc.Editor.SetMarks(marks)
+	if doc.Split != nil {
+		doc.Split.SetMarks(marks)
 	}
 }
 
-// Scroll to specific match.
-func (ui *UI) scrollToMatch(idx int) {
-	if len(ui.Matches) == 0 || idx < 0 || idx >= len(ui.Matches) {
+// Forget a document's matches, once they no longer fit its text or the search is closed.
+func (ui *UI) clearMatches(doc *Document) {
+	if len(doc.Matches) == 0 {
 		return
 	}
+	doc.Matches = []handling.Match{}
+	doc.CurrentMatchIdx = -1
+	ui.markMatches(doc)
+	if doc == ui.ActiveDocument() {
+		ui.MatchList.UnselectAll()
+		ui.MatchList.Refresh()
+		ui.updateSearchResults(doc)
+	}
+}
 
-	ui.Editor.SetText(ui.OriginalText)
+// Show how many matches there are and which one is selected.
+func (ui *UI) updateSearchResults(doc *Document) {
+	count := len(doc.Matches)
+	switch {
+	case ui.SearchTermEntry.Text == "":
+		ui.SearchResults.Importance = widget.MediumImportance
+		ui.SearchResults.SetText("Results: 0")
+	case count == 0:
+		ui.SearchResults.Importance = widget.WarningImportance
+		ui.SearchResults.SetText("No results")
+	case doc.CurrentMatchIdx >= 0:
+		ui.SearchResults.Importance = widget.MediumImportance
+		ui.SearchResults.SetText(fmt.Sprintf("%d of %d", doc.CurrentMatchIdx+1, count))
+	default:
+		ui.SearchResults.Importance = widget.MediumImportance
+		ui.SearchResults.SetText(fmt.Sprintf("Results: %d", count))
+	}
+}
 
-	// Get the current match index.
-	matchIdx := ui.Matches[idx]
-	ui.CurrentMatchIdx = idx
-	// Insert arrows around the match.
-	searchTerm := ui.SearchTermEntry.Text
-	matchLen := len(searchTerm)
+// Build a matcher from the search term and options, showing any error next to the term.
+func (ui *UI) matcher() (*handling.Matcher, bool) {
+	matcher, err := handling.NewMatcher(ui.SearchTermEntry.Text, handling.SearchOptions{
+		CaseSensitive: ui.CaseSensitiveCheck.Checked,
+		WholeWord:     ui.WholeWordCheck.Checked,
+		Regexp:        ui.RegexpCheck.Checked,
+	})
+	if err != nil {
+		ui.SearchError.SetText(err.Error())
+		ui.SearchError.Show()
+		return nil, false
+	}
+	ui.SearchError.Hide()
+	return matcher, true
+}
 
-	if matchIdx < 0 || matchIdx+matchLen > len(ui.OriginalText) {
+// Select a specific match in the editor, which scrolls it into view.
+func (ui *UI) scrollToMatch(idx int) {
+	doc := ui.ActiveDocument()
+	if len(doc.Matches) == 0 || idx < 0 || idx >= len(doc.Matches) {
 		return
 	}
 
-	highlighted := ui.OriginalText[:matchIdx] + "⬅️" + searchTerm + "➡️" + ui.OriginalText[matchIdx+matchLen:]
-	ui.Editor.SetText(highlighted)
-
-	ui.Editor.CursorColumn = matchIdx
-	ui.Editor.CursorRow = strings.Count(ui.Editor.Text[:matchIdx], "\n")
-
-	ui.ensureVisible(matchIdx)
+	doc.CurrentMatchIdx = idx
+	match := doc.Matches[idx]
+	if match.End > doc.Editor.Buffer.Len() {
+		return
+	}
 
-	ui.Editor.Refresh()
+	doc.Editor.Select(match.Start, match.End)
+	ui.MatchList.Select(idx)
+	ui.MatchList.ScrollTo(idx)
+	ui.updateSearchResults(doc)
 }
 
-// Ensure the current match is fully visible.
-func (ui *UI) ensureVisible(position int) {
-	if position < 0 || position >= len(ui.Editor.Text) {
+// Add a cursor selecting the next occurrence of the main selection, or
+// select the word at the cursor when nothing is selected.
+func (ui *UI) addNextOccurrence() {
+	editor := ui.activeEditor()
+	if editor == nil {
+		return
+	}
+	start, end := editor.Selection()
+	if start == end {
+		editor.SelectWord(start)
 		return
 	}
 
-	// Calculate line and column.
-	line := strings.Count(ui.Editor.Text[:position], "\n")
-	col := position - strings.LastIndex(ui.Editor.Text[:position], "\n") - 1
-
-	ui.Editor.CursorRow = line
-	ui.Editor.CursorColumn = col
+	matcher, err := handling.NewMatcher(editor.Buffer.Slice(start, end), handling.SearchOptions{CaseSensitive: true})
+	if err != nil {
+		return
+	}
+	matches := matcher.FindAll(editor.Text())
+	// Look after the selection first, then wrap around to the start.
+	for _, wrapped := range []bool{false, true} {
+		for _, match := range matches {
+			if (match.Start >= end) != wrapped && !editor.IsSelected(match.Start, match.End) {
+				editor.AddSelection(match.Start, match.End)
+				return
+			}
+		}
+	}
+}
 
-	visibleWidth := 40
-	if col > visibleWidth/2 {
-		ui.Editor.CursorColumn = col - (visibleWidth / 2)
-	} else {
-		ui.Editor.CursorColumn = 0
+// Create the list marking every match of the active document.
+func (ui *UI) newMatchList() *widget.List {
+	list := widget.NewList(
+		func() int {
+			if doc := ui.ActiveDocument(); doc != nil {
+				return len(doc.Matches)
+			}
+			return 0
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(ui.matchPreview(ui.ActiveDocument(), id))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		if doc := ui.ActiveDocument(); doc != nil && id != doc.CurrentMatchIdx {
+			ui.scrollToMatch(id)
+		}
 	}
+	return list
+}
 
-	if col+len(ui.SearchTermEntry.Text) < len(ui.Editor.Text)-10 {
-		ui.Editor.CursorColumn = col + 10
+// Describe a match by its line number and the line's text.
+func (ui *UI) matchPreview(doc *Document, idx int) string {
+	if doc == nil || idx >= len(doc.Matches) {
+		return ""
 	}
 
-	ui.Editor.Refresh()
+	row, _ := doc.Editor.RowCol(doc.Matches[idx].Start)
+	line := strings.TrimSpace(doc.Editor.Buffer.Line(row))
+	if runes := []rune(line); len(runes) > 40 {
+		line = string(runes[:40]) + "…"
+	}
+	return fmt.Sprintf("%d: %s", row+1, line)
 }
 
 // Navigate to the previous match.
 func (ui *UI) previousMatch() {
-	if len(ui.Matches) == 0 {
+	doc := ui.ActiveDocument()
+	if len(doc.Matches) == 0 {
 		return
 	}
 
-	ui.CurrentMatchIdx--
-	if ui.CurrentMatchIdx < 0 {
-		ui.CurrentMatchIdx = len(ui.Matches) - 1
+	doc.CurrentMatchIdx--
+	if doc.CurrentMatchIdx < 0 {
+		doc.CurrentMatchIdx = len(doc.Matches) - 1
 	}
 
-	ui.Editor.SetText(ui.OriginalText)
-	ui.scrollToMatch(ui.CurrentMatchIdx)
+	ui.scrollToMatch(doc.CurrentMatchIdx)
 }
 
 // Navigate to the next match.
 func (ui *UI) nextMatch() {
-	if len(ui.Matches) == 0 {
+	doc := ui.ActiveDocument()
+	if len(doc.Matches) == 0 {
 		return
 	}
 
-	ui.CurrentMatchIdx++
-	if ui.CurrentMatchIdx >= len(ui.Matches) {
-		ui.CurrentMatchIdx = 0
+	doc.CurrentMatchIdx++
+	if doc.CurrentMatchIdx >= len(doc.Matches) {
+		doc.CurrentMatchIdx = 0
 	}
 
-	ui.Editor.SetText(ui.OriginalText)
-	ui.scrollToMatch(ui.CurrentMatchIdx)
+	ui.scrollToMatch(doc.CurrentMatchIdx)
 }
 
 // Perform replace on current match.
 func (ui *UI) performReplaceCurrent() {
-	if len(ui.Matches) == 0 || ui.CurrentMatchIdx == -1 {
+	doc := ui.ActiveDocument()
+	if doc.Editor.ReadOnly {
+		dialog.ShowInformation("Replace", doc.Name()+" is read-only.", ui.Window)
+		return
+	}
+	if len(doc.Matches) == 0 || doc.CurrentMatchIdx == -1 {
 		dialog.ShowInformation("Replace", "No match selected.", ui.Window)
 		return
 	}
 
-	term := ui.SearchTermEntry.Text
-	replace := ui.ReplaceTermEntry.Text
-
-	if ui.OriginalText == "" {
-		ui.OriginalText = ui.Editor.Text
+	matcher, ok := ui.matcher()
+	if !ok {
+		return
 	}
-
-	currentIdx := ui.Matches[ui.CurrentMatchIdx]
-
-	if currentIdx >= 0 && currentIdx+len(term) <= len(ui.OriginalText) {
-		newText := ui.OriginalText[:currentIdx] + replace + ui.OriginalText[currentIdx+len(term):]
-		ui.Editor.SetText(newText)
-		ui.OriginalText = newText
+	text := doc.Editor.Text()
+	current := doc.Matches[doc.CurrentMatchIdx]
+
+	// The text may have been edited since the search ran, so only replace a match that still exists.
+	for _, match := range matcher.FindAll(text) {
+		if match.Start == current.Start && match.End == current.End {
+			replace := matcher.Expand(text, match, ui.ReplaceTermEntry.Text)
+			doc.Editor.SetText(text[:match.Start] + replace + text[match.End:])
+			break
+		}
 	}
 
 	ui.performSearch()
@@ -163,34 +343,34 @@ func (ui *UI) performReplaceCurrent() {
 
 // Perform replace-all.
 func (ui *UI) performReplaceAll() {
-	term := ui.SearchTermEntry.Text
-	replace := ui.ReplaceTermEntry.Text
-
-	if term == "" {
+	doc := ui.ActiveDocument()
+	if doc.Editor.ReadOnly {
+		dialog.ShowInformation("Replace", doc.Name()+" is read-only.", ui.Window)
+		return
+	}
+	if ui.SearchTermEntry.Text == "" {
 		dialog.ShowInformation("Replace", "Enter a search term.", ui.Window)
 		return
 	}
 
-	if ui.OriginalText == "" {
-		ui.OriginalText = ui.Editor.Text
+	matcher, ok := ui.matcher()
+	if !ok {
+		return
+	}
+	if text, count := matcher.ReplaceAll(doc.Editor.Text(), ui.ReplaceTermEntry.Text); count > 0 {
+		doc.Editor.SetText(text)
 	}
-
-	newText := strings.ReplaceAll(ui.OriginalText, term, replace)
-	ui.Editor.SetText(newText)
-	ui.OriginalText = newText
 
 	ui.performSearch()
 }
 
 // Toggle sidebar visibility.
 func (ui *UI) toggleSidebar() {
-	if ui.SidebarVisible {
-		if ui.OriginalText != "" {
-			ui.Editor.SetText(ui.OriginalText)
+	ui.SidebarVisible = !ui.SidebarVisible
+	if !ui.SidebarVisible {
+		for _, doc := range ui.Documents {
+			ui.clearMatches(doc)
 		}
-		ui.OriginalText = ""
 	}
-
-	ui.SidebarVisible = !ui.SidebarVisible
 	ui.Window.SetContent(ui.Layout())
 }
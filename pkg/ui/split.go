@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fyne.io/fyne/v2/container"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
+)
+
+// Show the active document in two editors, side by side or one above the
+// other. Both edit the same text but scroll and move their cursors apart.
+func (ui *UI) splitEditor(vertical bool) {
+	doc := ui.ActiveDocument()
+	if doc == nil {
+		return
+	}
+	if doc.Split == nil {
+		doc.Split = doc.Editor.NewView()
+		ui.markMatches(doc)
+	}
+
+	var split *container.Split
+	if vertical {
+		split = container.NewVSplit(doc.Editor, doc.Split)
+	} else {
+		split = container.NewHSplit(doc.Editor, doc.Split)
+	}
+	doc.Tab.Content = split
+	ui.Tabs.Refresh()
+	ui.Window.Canvas().Focus(doc.Split)
+}
+
+// Go back to a single editor for the active document.
+func (ui *UI) closeSplit() {
+	doc := ui.ActiveDocument()
+	if doc == nil || doc.Split == nil {
+		return
+	}
+	doc.Split.CloseView()
+	doc.Split = nil
+	doc.Tab.Content = doc.Editor
+	ui.Tabs.Refresh()
+	ui.Window.Canvas().Focus(doc.Editor)
+}
+
+// The editor of the active document that has the keyboard, or its first one.
+func (ui *UI) activeEditor() *handling.Editor {
+	doc := ui.ActiveDocument()
+	if doc == nil {
+		return nil
+	}
+	if doc.Split != nil && ui.Window.Canvas().Focused() == doc.Split {
+		return doc.Split
+	}
+	return doc.Editor
+}
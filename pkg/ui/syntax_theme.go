@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"github.com/Leda-Editor/Leda-Text-Editor/pkg/syntax"
+)
+
+// Token colors for light backgrounds.
+var lightSyntaxColors = map[fyne.ThemeColorName]color.Color{
+	syntax.ColorNameComment:  color.NRGBA{R: 0x6a, G: 0x73, B: 0x7d, A: 0xff},
+	syntax.ColorNameKeyword:  color.NRGBA{R: 0xd7, G: 0x3a, B: 0x49, A: 0xff},
+	syntax.ColorNameType:     color.NRGBA{R: 0xe3, G: 0x62, B: 0x09, A: 0xff},
+	syntax.ColorNameFunction: color.NRGBA{R: 0x6f, G: 0x42, B: 0xc1, A: 0xff},
+	syntax.ColorNameConstant: color.NRGBA{R: 0x00, G: 0x5c, B: 0xc5, A: 0xff},
+	syntax.ColorNameString:   color.NRGBA{R: 0x03, G: 0x2f, B: 0x62, A: 0xff},
+	syntax.ColorNameNumber:   color.NRGBA{R: 0x00, G: 0x5c, B: 0xc5, A: 0xff},
+	syntax.ColorNameOperator: color.NRGBA{R: 0xd7, G: 0x3a, B: 0x49, A: 0xff},
+	syntax.ColorNameProperty: color.NRGBA{R: 0x22, G: 0x86, B: 0x3a, A: 0xff},
+}
+
+// Token colors for dark backgrounds.
+var darkSyntaxColors = map[fyne.ThemeColorName]color.Color{
+	syntax.ColorNameComment:  color.NRGBA{R: 0x7f, G: 0x84, B: 0x8e, A: 0xff},
+	syntax.ColorNameKeyword:  color.NRGBA{R: 0xc6, G: 0x78, B: 0xdd, A: 0xff},
+	syntax.ColorNameType:     color.NRGBA{R: 0xe5, G: 0xc0, B: 0x7b, A: 0xff},
+	syntax.ColorNameFunction: color.NRGBA{R: 0x61, G: 0xaf, B: 0xef, A: 0xff},
+	syntax.ColorNameConstant: color.NRGBA{R: 0xd1, G: 0x9a, B: 0x66, A: 0xff},
+	syntax.ColorNameString:   color.NRGBA{R: 0x98, G: 0xc3, B: 0x79, A: 0xff},
+	syntax.ColorNameNumber:   color.NRGBA{R: 0xd1, G: 0x9a, B: 0x66, A: 0xff},
+	syntax.ColorNameOperator: color.NRGBA{R: 0x56, G: 0xb6, B: 0xc2, A: 0xff},
+	syntax.ColorNameProperty: color.NRGBA{R: 0xe0, G: 0x6c, B: 0x75, A: 0xff},
+}
+
+// Look up a token color, picking the palette that contrasts with the background.
+func syntaxColor(name fyne.ThemeColorName, background color.Color) (color.Color, bool) {
+	palette := lightSyntaxColors
+	if r, g, b, _ := background.RGBA(); r*299+g*587+b*114 < 0x8000*1000 {
+		palette = darkSyntaxColors
+	}
+	c, ok := palette[name]
+	return c, ok
+}
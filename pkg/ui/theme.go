@@ -262,8 +262,11 @@ func (th *Theme) Size(name fyne.ThemeSizeName) float32 {
 }
 
 // The following is needed as fyne's theme requires an implementation for them. They use base/default implementations.
-// Color returns the default color.
+// Color returns the default color, or the syntax highlighting color for token names.
 func (th *Theme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	if c, ok := syntaxColor(name, th.Base.Color(theme.ColorNameBackground, variant)); ok {
+		return c
+	}
 	return th.Base.Color(name, variant)
 }
 
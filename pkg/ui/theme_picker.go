@@ -155,23 +155,24 @@ func OpenThemePickerModal(app fyne.App, win fyne.Window, ui *UI) {
 	go func() {
 		for {
 			time.Sleep(time.Millisecond * 200)
-			newSize := win.Canvas().Size()
-
-			// Adjust modal size based on zoom level
-			modalWidth = newSize.Width * 0.5
-			modalHeight = newSize.Height * 0.8
-
-			// Apply constraints to prevent cutting off
-			if modalHeight < float32(minHeight) {
-				modalHeight = float32(minHeight)
-			}
-			if modalHeight > float32(maxHeight) {
-				modalHeight = float32(maxHeight)
-			}
-
-			// Resize modal
-			modal.Resize(fyne.NewSize(modalWidth, modalHeight))
-
+			fyne.Do(func() {
+				newSize := win.Canvas().Size()
+
+				// Adjust modal size based on zoom level
+				modalWidth = newSize.Width * 0.5
+				modalHeight = newSize.Height * 0.8
+
+				// Apply constraints to prevent cutting off
+				if modalHeight < float32(minHeight) {
+					modalHeight = float32(minHeight)
+				}
+				if modalHeight > float32(maxHeight) {
+					modalHeight = float32(maxHeight)
+				}
+
+				// Resize modal
+				modal.Resize(fyne.NewSize(modalWidth, modalHeight))
+			})
 		}
 	}()
 
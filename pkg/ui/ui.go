@@ -2,9 +2,15 @@ package ui
 
 import (
 	"fmt"
+	"net"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+	handling "github.com/Leda-Editor/Leda-Text-Editor/pkg/handling"
 )
 
 // UI specifies the user interface.
@@ -16,36 +22,89 @@ type UI struct {
 	Window fyne.Window
 
 	// Core state.
-	// Editor retains raw text in an edit buffer.
-	Editor *widget.Entry
-	// Markdown retains rich text interactions: clicks, hovers and longpresses.
-	Markdown *widget.RichText
+	// Documents holds every open document, one per tab.
+	Documents []*Document
+	// Tabs shows the open documents.
+	Tabs *container.DocTabs
+	// Markdown holds the preview, a rich text for each block of the source.
+	Markdown *fyne.Container
+	// MarkdownScroll scrolls the Markdown preview.
+	MarkdownScroll *container.Scroll
 	// MenuBar adds a menu to the window.
 	MenuBar *fyne.Container
 	// Theme allows to customize theme, such as font size.
 	Theme *Theme
+	// Autosave writes modified documents in the background.
+	Autosave *Autosave
 	// CharacterLabel & LineLabel creates labels for the respective counters.
 	CharacterLabel *widget.Label
 	LineLabel      *widget.Label
+	// LanguageLabel names the language of the active document.
+	LanguageLabel *widget.Label
+	// LargeFileStatus shows when the active document is in large-file mode.
+	LargeFileStatus *fyne.Container
+	// LargeFileLabel says whether the large file is read-only.
+	LargeFileLabel *widget.Label
+	// GitStatus shows when the active document's file is in a git repository.
+	GitStatus *fyne.Container
+	// GitLabel names the branch and how the file differs from the last commit.
+	GitLabel *widget.Label
 
 	// Search/Replace Sidebar
 	// SearchTermEntry where you can type text to find.
-	SearchTermEntry *widget.Entry
+	SearchTermEntry *FindEntry
+	// SearchError shows why the search term is invalid.
+	SearchError *widget.Label
+	// CaseSensitiveCheck, WholeWordCheck & RegexpCheck toggle the search modes.
+	CaseSensitiveCheck *widget.Check
+	WholeWordCheck     *widget.Check
+	RegexpCheck        *widget.Check
 	// ReplaceTermEntry where you can type text to replace matched occurrences.
 	ReplaceTermEntry *widget.Entry
-	// SearchResults displays number of matches.
+	// SearchResults displays the selected match and number of matches.
 	SearchResults *widget.Label
-	// Matches hold indices of all occurrences.
-	Matches []int
-	// CurrentMatchIdx keeps track of current match.
-	CurrentMatchIdx int
+	// MatchList marks every match in the active document.
+	MatchList *widget.List
 	// SidebarVisible indicates whether sidebar is currently visible.
 	SidebarVisible bool
-	// OriginalText stores original text before search markers are added.
-	OriginalText string
+
+	// Explorer lists the files of the open project folder.
+	Explorer *Explorer
+	// ExplorerVisible indicates whether the explorer is visible.
+	ExplorerVisible bool
+
+	// FolderSearch searches the files of a folder.
+	FolderSearch *FolderSearch
+	// FolderSearchVisible indicates whether the Find in Folder panel is visible.
+	FolderSearchVisible bool
 
 	// Markdown visibility toggle
 	ShowMarkdown bool
+	// markdownRows holds the first source row of each preview block, and
+	// markdownTexts the source it was rendered from.
+	markdownRows  []int
+	markdownTexts []string
+	// markdownSyncing is set while the preview scrolls to follow the editor.
+	markdownSyncing bool
+
+	// compares holds the open compare windows.
+	compares []*Compare
+
+	// searchTimer debounces incremental searches.
+	searchTimer *time.Timer
+
+	// untitledCount numbers new untitled documents.
+	untitledCount int
+	// journalDir and journalStop track the crash recovery journal, and
+	// journalLock keeps a late write from outliving its removal.
+	journalDir  fyne.URI
+	journalStop chan struct{}
+	journalLock sync.Mutex
+	// waiting holds the documents --wait is waiting on to be closed.
+	waiting  []*waiter
+	waitLock sync.Mutex
+	// listener receives files from other Leda processes.
+	listener net.Listener
 }
 
 // NewUI initializes the UI.
@@ -55,20 +114,55 @@ func NewUI(app fyne.App, win fyne.Window) *UI {
 	ui := &UI{
 		App:              app,
 		Window:           win,
-		Editor:           widget.NewMultiLineEntry(),
-		Markdown:         widget.NewRichTextFromMarkdown(""),
+		Tabs:             container.NewDocTabs(),
+		Markdown:         container.New(layout.NewCustomPaddedVBoxLayout(0)),
 		Theme:            theme,
 		CharacterLabel:   widget.NewLabelWithStyle("Characters: 0", fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
 		LineLabel:        widget.NewLabelWithStyle("Lines: 0", fyne.TextAlignLeading, fyne.TextStyle{Bold: false}),
-		SearchTermEntry:  widget.NewEntry(),
+		LanguageLabel:    widget.NewLabel("Plain Text"),
+		LargeFileLabel:   widget.NewLabel("Large File"),
+		GitLabel:         widget.NewLabel(""),
+		SearchTermEntry:  NewFindEntry(),
 		ReplaceTermEntry: widget.NewEntry(),
 		SearchResults:    widget.NewLabel("Results: 0"),
 		SidebarVisible:   false,
-		Matches:          []int{},
-		CurrentMatchIdx:  -1,
-		OriginalText:     "",
 		ShowMarkdown:     true,
 	}
+	ui.MarkdownScroll = container.NewScroll(ui.Markdown)
+	ui.MarkdownScroll.OnScrolled = ui.syncEditor
+	ui.LargeFileStatus = container.NewHBox(widget.NewLabel(" | "), ui.LargeFileLabel)
+	ui.LargeFileStatus.Hide()
+	ui.GitStatus = container.NewHBox(widget.NewLabel(" | "), ui.GitLabel)
+	ui.GitStatus.Hide()
+	ui.MatchList = ui.newMatchList()
+	ui.FolderSearch = NewFolderSearch(ui)
+	ui.Explorer = NewExplorer(ui)
+	ui.SearchError = widget.NewLabel("")
+	ui.SearchError.Importance = widget.DangerImportance
+	ui.SearchError.Wrapping = fyne.TextWrapWord
+	ui.SearchError.Hide()
+	ui.CaseSensitiveCheck = widget.NewCheck("Match case", nil)
+	ui.CaseSensitiveCheck.SetChecked(true)
+	ui.WholeWordCheck = widget.NewCheck("Whole word", nil)
+	ui.RegexpCheck = widget.NewCheck("Regular expression", nil)
+
+	// Search as the term or options change.
+	ui.SearchTermEntry.OnChanged = func(string) { ui.scheduleSearch(true) }
+	ui.SearchTermEntry.OnNext = ui.nextMatch
+	ui.SearchTermEntry.OnPrevious = ui.previousMatch
+	for _, check := range []*widget.Check{ui.CaseSensitiveCheck, ui.WholeWordCheck, ui.RegexpCheck} {
+		check.OnChanged = func(bool) { ui.scheduleSearch(true) }
+	}
+
+	ui.Tabs.CreateTab = func() *container.TabItem {
+		ui.NewDocument()
+		return nil
+	}
+	ui.Tabs.CloseIntercept = func(item *container.TabItem) { ui.CloseDocument(ui.documentForTab(item)) }
+	ui.Tabs.OnSelected = func(item *container.TabItem) { ui.documentSelected(ui.documentForTab(item)) }
+	ui.Autosave = NewAutosave(ui)
+	ui.NewDocument()
+	ui.Window.SetCloseIntercept(func() { ui.Exit() })
 
 	ui.MenuBar = ui.CreateMenuBar()
 	ui.Theme.ApplyTheme()
@@ -77,19 +171,14 @@ func NewUI(app fyne.App, win fyne.Window) *UI {
 
 	ApplyUserTheme(ui)
 
-	// Update Markdown Preview whenever text changes.
-	ui.Editor.OnChanged = func(content string) {
-		ui.RenderMarkdown(content)
-		ui.UpdateCounts(content)
-	}
-
 	return ui
 }
 
-// Updates Markdown Preview.
+// Updates Markdown Preview, keeping it beside the cursor.
 func (ui *UI) RenderMarkdown(input string) {
-	ui.Markdown.ParseMarkdown(input)
-	ui.Markdown.Refresh()
+	ui.renderMarkdownBlocks(input)
+	ui.MarkdownScroll.Refresh()
+	ui.syncPreview()
 }
 
 // Zoom In/Out.
@@ -109,9 +198,9 @@ func (ui *UI) toggleMarkdownPreview() {
 }
 
 // Update character & line counts.
-func (ui *UI) UpdateCounts(content string) {
-	charCount := len(content)                                    // Count characters.
-	lineCount := len(widget.NewTextGridFromString(content).Rows) // Count lines.
+func (ui *UI) UpdateCounts(buffer *handling.Buffer) {
+	charCount := buffer.Len()   // Count characters.
+	lineCount := buffer.Lines() // Count lines.
 
 	// Update the labels.
 	ui.CharacterLabel.SetText(fmt.Sprintf("Characters: %d", charCount))